@@ -0,0 +1,217 @@
+package pagecard
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultConcurrency is the maximum number of webpages retrieved at
+	// the same time by GetAll if the options do not specify one.
+	DefaultConcurrency = 8
+	// DefaultHostConcurrency is the maximum number of webpages of the same
+	// host retrieved at the same time by GetAll if the options do not
+	// specify one.
+	DefaultHostConcurrency = 2
+)
+
+// BatchOptions configures how GetAll retrieves a batch of webpages.
+type BatchOptions struct {
+	Options
+	// Concurrency is the maximum number of webpages retrieved at the same
+	// time. If zero, DefaultConcurrency is used.
+	Concurrency int
+	// HostConcurrency is the maximum number of webpages of the same host
+	// retrieved at the same time. If zero, DefaultHostConcurrency is used.
+	HostConcurrency int
+	// HostDelay is the minimum time between the start of two requests to
+	// the same host.
+	HostDelay time.Duration
+}
+
+// Result is the outcome of retrieving one of the webpages of a batch.
+type Result struct {
+	URL  string
+	Info *Info
+	Err  error
+}
+
+// GetAll retrieves the Info of all the webpages with the given URLs, and
+// sends their results to the returned channel as they complete. The
+// channel is closed once there is a result for every URL. A failure
+// retrieving a webpage is reported in its result and does not stop the
+// batch. If the context is done, the webpages not retrieved yet result in
+// the error of the context. If opts is nil, the default options are used.
+func GetAll(ctx context.Context, urls []string, opts *BatchOptions) <-chan *Result {
+	if opts == nil {
+		opts = new(BatchOptions)
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	hostConcurrency := opts.HostConcurrency
+	if hostConcurrency <= 0 {
+		hostConcurrency = DefaultHostConcurrency
+	}
+
+	var (
+		results = make(chan *Result, len(urls))
+		slots   = make(chan struct{}, concurrency)
+		hosts   = newHostLimiter(hostConcurrency, opts.HostDelay)
+		wg      sync.WaitGroup
+	)
+
+	for _, u := range urls {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			info, err := getPolitely(ctx, u, &opts.Options, slots, hosts)
+			results <- &Result{u, info, err}
+		}(u)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// getPolitely retrieves the Info of the webpage once there is a free slot
+// for its host, enough time passed since the last request to its host and
+// there is a free global slot. Webpages wait for their host without a
+// global slot, so they do not hold the slots of other hosts, and the start
+// of the request is reserved once the global slot is taken, so requests to
+// the same host are spaced by the time they actually start.
+func getPolitely(
+	ctx context.Context,
+	rawurl string,
+	opts *Options,
+	slots chan struct{},
+	hosts *hostLimiter,
+) (*Info, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	host := strings.ToLower(u.Host)
+	release, err := hosts.acquire(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	for {
+		if err := hosts.wait(ctx, host); err != nil {
+			return nil, err
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// Another request to the host may have started while this one
+		// was waiting for the global slot.
+		if hosts.start(host) {
+			break
+		}
+		<-slots
+	}
+	defer func() { <-slots }()
+
+	return GetContext(ctx, rawurl, opts)
+}
+
+// hostLimiter limits the number of concurrent requests to every host and
+// the time between them.
+type hostLimiter struct {
+	mut         sync.Mutex
+	concurrency int
+	delay       time.Duration
+	hosts       map[string]*hostState
+}
+
+type hostState struct {
+	slots chan struct{}
+	next  time.Time
+}
+
+func newHostLimiter(concurrency int, delay time.Duration) *hostLimiter {
+	return &hostLimiter{
+		concurrency: concurrency,
+		delay:       delay,
+		hosts:       make(map[string]*hostState),
+	}
+}
+
+func (l *hostLimiter) state(host string) *hostState {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	s, ok := l.hosts[host]
+	if !ok {
+		s = &hostState{slots: make(chan struct{}, l.concurrency)}
+		l.hosts[host] = s
+	}
+	return s
+}
+
+// acquire waits for a free slot for the host and returns the function to
+// release it.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	s := l.state(host)
+	select {
+	case s.slots <- struct{}{}:
+		return func() { <-s.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// wait waits until the delay since the last request to the host passed.
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	s := l.state(host)
+	l.mut.Lock()
+	d := time.Until(s.next)
+	l.mut.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// start reports whether a request to the host can start now, in which case
+// the next one has to wait for the delay of the limiter.
+func (l *hostLimiter) start(host string) bool {
+	s := l.state(host)
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	now := time.Now()
+	if now.Before(s.next) {
+		return false
+	}
+
+	s.next = now.Add(l.delay)
+	return true
+}
@@ -0,0 +1,208 @@
+package pagecard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// politeServer records the maximum number of concurrent requests it
+// receives and the time every request starts.
+type politeServer struct {
+	*httptest.Server
+	mut      sync.Mutex
+	inFlight int
+	max      int
+	starts   []time.Time
+}
+
+func newPoliteServer() *politeServer {
+	s := new(politeServer)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mut.Lock()
+		s.inFlight++
+		if s.inFlight > s.max {
+			s.max = s.inFlight
+		}
+		s.starts = append(s.starts, time.Now())
+		s.mut.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprintf(w, `<meta property="og:title" content="%s">`, r.URL.Path)
+
+		s.mut.Lock()
+		s.inFlight--
+		s.mut.Unlock()
+	}))
+	return s
+}
+
+func (s *politeServer) requests() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return len(s.starts)
+}
+
+func TestGetAll(t *testing.T) {
+	srv1, srv2 := newPoliteServer(), newPoliteServer()
+	defer srv1.Close()
+	defer srv2.Close()
+
+	var urls []string
+	for i := 0; i < 6; i++ {
+		urls = append(urls, fmt.Sprintf("%s/%d", srv1.URL, i), fmt.Sprintf("%s/%d", srv2.URL, i))
+	}
+	urls = append(urls, "://invalid")
+
+	// Requests reach the server a bit after they start, and that time
+	// varies between requests, so their arrivals may be closer together
+	// than the delay by up to jitter.
+	const (
+		delay  = 40 * time.Millisecond
+		jitter = 10 * time.Millisecond
+	)
+	results := GetAll(context.Background(), urls, &BatchOptions{
+		Options:         Options{SkipOEmbed: true},
+		Concurrency:     3,
+		HostConcurrency: 2,
+		HostDelay:       delay,
+	})
+
+	assert := assert.New(t)
+	titles := make(map[string]string)
+	for r := range results {
+		if r.URL == "://invalid" {
+			assert.NotNil(r.Err)
+			continue
+		}
+
+		assert.Nil(r.Err)
+		titles[r.URL] = r.Info.OpenGraph.Title
+	}
+
+	assert.Equal(len(titles), 12)
+	assert.Equal(titles[srv2.URL+"/5"], "/5")
+
+	for _, s := range []*politeServer{srv1, srv2} {
+		assert.True(s.max <= 2)
+		assert.Equal(len(s.starts), 6)
+		for i := 1; i < len(s.starts); i++ {
+			assert.True(s.starts[i].Sub(s.starts[i-1]) >= delay-jitter)
+		}
+	}
+}
+
+func TestGetAllDefaults(t *testing.T) {
+	srv := newPoliteServer()
+	defer srv.Close()
+
+	assert := assert.New(t)
+	for r := range GetAll(context.Background(), []string{srv.URL + "/1", srv.URL + "/2"}, nil) {
+		assert.Nil(r.Err)
+	}
+}
+
+func TestGetAllHostDelaySlots(t *testing.T) {
+	srv1, srv2 := newPoliteServer(), newPoliteServer()
+	defer srv1.Close()
+	defer srv2.Close()
+
+	// The second request to srv1 waits for the delay of its host, which
+	// must not keep the only global slot from the request to srv2.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	results := GetAll(ctx, []string{srv1.URL + "/1", srv1.URL + "/2", srv2.URL + "/1"}, &BatchOptions{
+		Options:     Options{SkipOEmbed: true},
+		Concurrency: 1,
+		HostDelay:   time.Hour,
+	})
+
+	assert := assert.New(t)
+	errs := make(map[string]error)
+	for r := range results {
+		errs[r.URL] = r.Err
+	}
+
+	assert.Nil(errs[srv2.URL+"/1"])
+	assert.True(errs[srv1.URL+"/1"] == nil || errs[srv1.URL+"/2"] == nil)
+	assert.True(errs[srv1.URL+"/1"] == context.DeadlineExceeded || errs[srv1.URL+"/2"] == context.DeadlineExceeded)
+	assert.Equal(srv1.requests(), 1)
+	assert.Equal(srv2.requests(), 1)
+}
+
+func TestGetAllBusySlots(t *testing.T) {
+	received := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		time.Sleep(150 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	srv := newPoliteServer()
+	defer srv.Close()
+
+	const (
+		delay  = 40 * time.Millisecond
+		jitter = 10 * time.Millisecond
+	)
+
+	var (
+		opts  = &Options{SkipOEmbed: true}
+		slots = make(chan struct{}, 2)
+		hosts = newHostLimiter(2, delay)
+		wg    sync.WaitGroup
+	)
+
+	get := func(u string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			getPolitely(context.Background(), u, opts, slots, hosts)
+		}()
+	}
+
+	// The slow requests hold both global slots for longer than the delay,
+	// so the requests to srv start once they are released, and must still
+	// be spaced by the delay.
+	get(slow.URL + "/1")
+	<-received
+	get(slow.URL + "/2")
+	<-received
+	get(srv.URL + "/1")
+	get(srv.URL + "/2")
+	wg.Wait()
+
+	assert := assert.New(t)
+	assert.Equal(len(srv.starts), 2)
+	assert.True(srv.starts[1].Sub(srv.starts[0]) >= delay-jitter)
+}
+
+func TestGetAllCanceled(t *testing.T) {
+	srv := newPoliteServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := GetAll(ctx, []string{srv.URL + "/1", srv.URL + "/2", srv.URL + "/3"}, &BatchOptions{
+		Options:   Options{SkipOEmbed: true},
+		HostDelay: time.Hour,
+	})
+
+	assert := assert.New(t)
+	r := <-results
+	assert.Nil(r.Err)
+
+	cancel()
+	var n int
+	for r := range results {
+		assert.Equal(r.Err, context.Canceled)
+		n++
+	}
+	assert.Equal(n, 2)
+}
@@ -0,0 +1,85 @@
+package pagecard
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/oembed"
+)
+
+// infoKey returns the key of the Info of the webpage with the given URL in
+// the cache of the options. It contains the options that change the Info,
+// so Infos retrieved with different options do not replace each other.
+func infoKey(url string, opts *Options, extractors []Extractor) string {
+	names := make([]string, len(extractors))
+	for i, e := range extractors {
+		names[i] = e.Name()
+	}
+
+	var oembedOpts oembed.Options
+	if opts.OEmbed != nil {
+		oembedOpts = *opts.OEmbed
+	}
+
+	return fmt.Sprintf(
+		"info %s extractors=%s oembed=%t %+v probe=%t",
+		content.NormalizeURL(url),
+		strings.Join(names, ","),
+		!opts.SkipOEmbed,
+		oembedOpts,
+		opts.ImageProber != nil,
+	)
+}
+
+// cachedInfo returns the Info stored in the cache with the given key, if
+// any, along with the entry it is stored in.
+func cachedInfo(cache content.Cache, key string) (*Info, *content.CacheEntry) {
+	entry, ok := cache.Get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	var info Info
+	if err := json.Unmarshal(entry.Body, &info); err != nil {
+		return nil, nil
+	}
+
+	if info.Fetch != nil {
+		info.Fetch.Attempts = 0
+	}
+	return &info, entry
+}
+
+// unchanged reports whether the document has the same content as the one
+// the Info stored in the given entry was extracted from, because its
+// response was revalidated or has the same validators.
+func unchanged(entry *content.CacheEntry, doc *content.Document) bool {
+	if entry.URL != doc.URL || (entry.ETag == "" && entry.LastModified == "") {
+		return false
+	}
+	return entry.ETag == doc.ETag && entry.LastModified == doc.LastModified
+}
+
+// cacheInfo stores the Info extracted from the document in the cache with
+// the given key, if the document can be cached. It is fresh while the
+// document is, and can be reused afterwards if the document is unchanged.
+func cacheInfo(cache content.Cache, key string, info *Info, doc *content.Document) {
+	if doc.Expires.IsZero() {
+		return
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return
+	}
+
+	cache.Set(key, &content.CacheEntry{
+		FetchInfo:    content.FetchInfo{URL: doc.URL},
+		Body:         data,
+		Expires:      doc.Expires,
+		ETag:         doc.ETag,
+		LastModified: doc.LastModified,
+	})
+}
@@ -0,0 +1,120 @@
+package pagecard
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/opengraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCache(t *testing.T) {
+	requests := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/max-age":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		fmt.Fprintf(w, `<meta property="og:title" content="%s %d">`, r.URL.Path, requests[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	cache := content.NewMemoryCache(10, 0)
+	opts := &Options{Fetcher: new(content.Fetcher), SkipOEmbed: true, Cache: cache}
+
+	assert := assert.New(t)
+	info, err := GetWithOptions(srv.URL+"/max-age", opts)
+	assert.Nil(err)
+	assert.Equal(info.OpenGraph.Title, "/max-age 1")
+	assert.Equal(info.Fetch.Attempts, 1)
+
+	cached, err := GetWithOptions(srv.URL+"/max-age#foo", opts)
+	assert.Nil(err)
+	info.Fetch.Attempts = 0
+	assert.Equal(cached, info)
+	assert.Equal(requests["/max-age"], 1)
+
+	// Infos retrieved with other options are cached separately.
+	info, err = GetWithOptions(srv.URL+"/max-age", &Options{
+		Fetcher:    opts.Fetcher,
+		SkipOEmbed: true,
+		Extractors: []string{opengraph.ExtractorName},
+		Cache:      cache,
+	})
+	assert.Nil(err)
+	assert.Equal(info.OpenGraph.Title, "/max-age 2")
+	assert.Nil(info.Twitter)
+
+	for i := 1; i <= 2; i++ {
+		info, err = GetWithOptions(srv.URL+"/no-store", opts)
+		assert.Nil(err)
+		assert.Equal(info.OpenGraph.Title, fmt.Sprintf("/no-store %d", i))
+	}
+
+	// The cache may be shared with the fetcher.
+	opts = &Options{Fetcher: &content.Fetcher{Cache: cache}, SkipOEmbed: true, Cache: cache}
+	for i := 0; i < 2; i++ {
+		info, err = GetWithOptions(srv.URL+"/max-age?shared", opts)
+		assert.Nil(err)
+		assert.Equal(info.OpenGraph.Title, "/max-age 3")
+	}
+}
+
+func TestGetCacheRevalidate(t *testing.T) {
+	var (
+		version                       = 1
+		full, notModified, oembedReqs int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oembed" {
+			oembedReqs++
+			fmt.Fprint(w, `{"type":"rich","title":"Foo"}`)
+			return
+		}
+
+		etag := fmt.Sprintf(`"v%d"`, version)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		full++
+		fmt.Fprintf(w, `<meta property="og:title" content="Version %d">`, version)
+		fmt.Fprint(w, `<link rel="alternate" type="application/json+oembed" href="/oembed">`)
+	}))
+	defer srv.Close()
+
+	cache := content.NewMemoryCache(10, 0)
+	opts := &Options{Fetcher: &content.Fetcher{Cache: cache}, Cache: cache}
+
+	assert := assert.New(t)
+	for i := 0; i < 2; i++ {
+		info, err := GetWithOptions(srv.URL, opts)
+		assert.Nil(err)
+		assert.Equal(info.OpenGraph.Title, "Version 1")
+		assert.Equal(info.OEmbed.Title, "Foo")
+	}
+
+	// The page is revalidated, and its Info reused without requesting its
+	// oEmbed data again.
+	assert.Equal(full, 1)
+	assert.Equal(notModified, 1)
+	assert.Equal(oembedReqs, 1)
+
+	version = 2
+	info, err := GetWithOptions(srv.URL, opts)
+	assert.Nil(err)
+	assert.Equal(info.OpenGraph.Title, "Version 2")
+	assert.Equal(full, 2)
+	assert.Equal(oembedReqs, 2)
+}
@@ -0,0 +1,117 @@
+// Command pagecard-server runs the HTTP service of package server, which
+// retrieves the previews of webpages.
+//
+// Usage:
+//
+//	pagecard-server [flags]
+//
+// Since the URLs are given by the clients of the service, requests to
+// private, loopback, link-local and multicast addresses are refused unless
+// -allow-private is given.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/server"
+)
+
+func main() {
+	var (
+		addr          = flag.String("addr", ":8080", "address to listen on")
+		timeout       = flag.Duration("timeout", server.DefaultTimeout, "maximum time to retrieve a webpage")
+		maxConcurrent = flag.Int("max-concurrent", server.DefaultMaxConcurrent, "maximum number of webpages retrieved at the same time")
+		cacheEntries  = flag.Int("cache-entries", server.DefaultCacheEntries, "maximum number of pages cached in memory")
+		cacheBytes    = flag.Int64("cache-bytes", server.DefaultCacheBytes, "maximum size of the pages cached in memory")
+		cacheDir      = flag.String("cache-dir", "", "cache the pages in the given directory instead of in memory")
+		cacheMaxStale = flag.Duration("cache-max-stale", content.DefaultMaxStale, "time an expired page is kept in the cache directory to revalidate it")
+		maxBodyBytes  = flag.Int64("max-body-bytes", server.DefaultMaxBodyBytes, "maximum number of bytes of a page read")
+		cacheMinTTL   = flag.Duration("cache-min-ttl", time.Minute, "minimum time a page is cached")
+		userAgent     = flag.String("user-agent", "pagecard (+https://github.com/mvader/pagecard)", "User-Agent header sent with the requests")
+		robots        = flag.Bool("robots", false, "respect the robots.txt of the webpages")
+		allowPrivate  = flag.Bool("allow-private", false, "allow requests to private and loopback addresses")
+	)
+	flag.Parse()
+
+	fetcher := &content.Fetcher{
+		MaxRefreshes:    content.DefaultFetcher.MaxRefreshes,
+		MaxRefreshDelay: content.DefaultFetcher.MaxRefreshDelay,
+		MaxBodyBytes:    *maxBodyBytes,
+		CacheMinTTL:     *cacheMinTTL,
+		UserAgent:       *userAgent,
+	}
+
+	if *cacheDir != "" {
+		cache, err := content.NewDiskCache(*cacheDir)
+		if err != nil {
+			log.Fatalf("pagecard-server: %s", err)
+		}
+
+		cache.MaxStale = *cacheMaxStale
+		go prune(cache)
+		fetcher.Cache = cache
+	} else {
+		fetcher.Cache = content.NewMemoryCache(*cacheEntries, *cacheBytes)
+	}
+
+	if !*allowPrivate {
+		fetcher.Client = content.NewSafeClient(nil)
+	}
+
+	if *robots {
+		fetcher.Robots = new(content.Robots)
+	}
+
+	opts := &server.Options{Timeout: *timeout, MaxConcurrent: *maxConcurrent}
+	opts.Fetcher = fetcher
+	opts.Cache = fetcher.Cache
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(opts),
+		ReadHeaderTimeout: 5 * time.Second,
+		// Responses are written once the webpage is retrieved, so the
+		// write timeout must allow for the whole retrieval.
+		WriteTimeout: *timeout + 5*time.Second,
+		IdleTimeout:  time.Minute,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("pagecard-server: %s", err)
+		}
+	}()
+
+	log.Printf("pagecard-server: listening on %s", *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatalf("pagecard-server: %s", err)
+	}
+	<-done
+}
+
+// prune removes the unusable entries of the cache every hour, so the
+// entries that are not requested again do not accumulate.
+func prune(cache *content.DiskCache) {
+	for {
+		if err := cache.Prune(); err != nil {
+			log.Printf("pagecard-server: pruning cache: %s", err)
+		}
+		time.Sleep(time.Hour)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/mvader/pagecard/content"
+)
+
+const defaultUserAgent = "pagecard (+https://github.com/mvader/pagecard)"
+
+// input are the flags of the commands that read a webpage.
+type input struct {
+	userAgent string
+	timeout   time.Duration
+	file      string
+}
+
+func (in *input) register(fs *flag.FlagSet) {
+	fs.StringVar(&in.userAgent, "user-agent", defaultUserAgent, "User-Agent header sent with the requests")
+	fs.DurationVar(&in.timeout, "timeout", 10*time.Second, "maximum time to retrieve the webpage")
+	fs.StringVar(&in.file, "file", "", "read the HTML from the given file instead of fetching it, or - for stdin")
+}
+
+// validArgs reports whether the positional arguments are valid, that is,
+// a URL to fetch or, if the webpage is read from a file, an optional URL
+// to resolve its links.
+func (in *input) validArgs(fs *flag.FlagSet) bool {
+	return fs.NArg() == 1 || (fs.NArg() == 0 && in.file != "")
+}
+
+func (in *input) fetcher() *content.Fetcher {
+	return &content.Fetcher{
+		MaxRefreshes:    content.DefaultFetcher.MaxRefreshes,
+		MaxRefreshDelay: content.DefaultFetcher.MaxRefreshDelay,
+		UserAgent:       in.userAgent,
+	}
+}
+
+// context returns the context the webpage is fetched with.
+func (in *input) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), in.timeout)
+}
+
+// open returns the reader of the file the webpage is read from.
+func (in *input) open(stdin io.Reader) (io.ReadCloser, error) {
+	if in.file == "-" {
+		return ioutil.NopCloser(stdin), nil
+	}
+	return os.Open(in.file)
+}
+
+// document returns the document of the webpage, read from the file or
+// fetched from the given URL if there is no file.
+func (in *input) document(url string, stdin io.Reader) (*content.Document, error) {
+	if in.file == "" {
+		ctx, cancel := in.context()
+		defer cancel()
+		return in.fetcher().FetchContext(ctx, url)
+	}
+
+	r, err := in.open(stdin)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	doc, err := content.ParseDocument(r, in.fetcher().Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.URL = url
+	return doc, nil
+}
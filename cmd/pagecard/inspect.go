@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mvader/pagecard"
+)
+
+// inspect prints the Info of a webpage, fetched from its URL or read from
+// a file.
+func inspect(args []string, stdin io.Reader, stdout io.Writer) error {
+	var in input
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	in.register(fs)
+	var (
+		format     = fs.String("format", "table", "output format: table or json")
+		extractors = fs.String("extractors", "", "comma-separated names of the extractors to run (default all)")
+		skipOEmbed = fs.Bool("no-oembed", false, "do not retrieve the oEmbed data of the webpage")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pagecard inspect [flags] <url>")
+		fmt.Fprintln(fs.Output(), "       pagecard inspect [flags] -file <path> [url]")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+
+	if !in.validArgs(fs) {
+		fs.Usage()
+		return errUsage
+	}
+
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	opts := &pagecard.Options{
+		Fetcher:    in.fetcher(),
+		SkipOEmbed: *skipOEmbed,
+	}
+	if *extractors != "" {
+		opts.Extractors = strings.Split(*extractors, ",")
+	}
+
+	info, err := read(&in, fs.Arg(0), stdin, opts)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		return writeJSON(stdout, info)
+	}
+	return writeTable(stdout, info)
+}
+
+// read returns the Info of the webpage in the file of the input, or
+// fetches it from the URL if there is no file.
+func read(in *input, url string, stdin io.Reader, opts *pagecard.Options) (*pagecard.Info, error) {
+	if in.file == "" {
+		ctx, cancel := in.context()
+		defer cancel()
+		return pagecard.GetContext(ctx, url, opts)
+	}
+
+	r, err := in.open(stdin)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return pagecard.Parse(r, url, opts)
+}
+
+func checkFormat(format string) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("unknown format: %s", format)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mvader/pagecard"
+	"github.com/stretchr/testify/assert"
+)
+
+const page = `<head>
+<meta property="og:title" content="Foo">
+<meta property="og:image" content="http://foo.bar/a.png">
+<meta property="og:image:width" content="200">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:site" content="@foo">
+<meta name="twitter:creator" content="@bar">
+</head>`
+
+func TestInspectTable(t *testing.T) {
+	var out bytes.Buffer
+	err := inspect([]string{"-file", "-", "http://foo.bar/"}, strings.NewReader(page), &out)
+
+	assert := assert.New(t)
+	assert.Nil(err)
+	assert.Equal(strings.Split(out.String(), "\n"), []string{
+		"FIELD                      VALUE",
+		"OpenGraph.Title            Foo",
+		"OpenGraph.Images[0].URL    http://foo.bar/a.png",
+		"OpenGraph.Images[0].Width  200",
+		"Twitter.Type               summary_large_image",
+		"Twitter.Site.User          @foo",
+		"Twitter.Creator.User       @bar",
+		"Fetch.URL                  http://foo.bar/",
+		"",
+	})
+}
+
+func TestInspectJSON(t *testing.T) {
+	var userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		fmt.Fprint(w, page)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	err := inspect([]string{
+		"-format", "json",
+		"-user-agent", "test",
+		"-extractors", "opengraph",
+		srv.URL,
+	}, nil, &out)
+
+	assert := assert.New(t)
+	assert.Nil(err)
+	assert.Equal(userAgent, "test")
+
+	var info pagecard.Info
+	assert.Nil(json.Unmarshal(out.Bytes(), &info))
+	assert.Equal(info.OpenGraph.Title, "Foo")
+	assert.Nil(info.Twitter)
+}
+
+func TestInspectErrors(t *testing.T) {
+	cases := []struct {
+		args []string
+		err  string
+	}{
+		{nil, errUsage.Error()},
+		{[]string{"-unknown", "http://foo.bar"}, errUsage.Error()},
+		{[]string{"http://foo.bar", "http://baz.qux"}, errUsage.Error()},
+		{[]string{"-format", "xml", "-file", "-"}, "unknown format: xml"},
+		{[]string{"-extractors", "missing", "-file", "-"}, "unknown extractor: missing"},
+		{[]string{"-file", "testdata/missing.html"}, "open testdata/missing.html: no such file or directory"},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		var out bytes.Buffer
+		err := inspect(c.args, strings.NewReader(page), &out)
+		if assert.NotNil(err, c.args) {
+			assert.Equal(err.Error(), c.err, c.args)
+		}
+	}
+}
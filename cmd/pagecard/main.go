@@ -0,0 +1,73 @@
+// Command pagecard inspects and validates the metadata used to build the
+// previews of webpages.
+//
+// Usage:
+//
+//	pagecard inspect [flags] <url>
+//	pagecard inspect [flags] -file <path> [url]
+//	pagecard validate [flags] <url>
+//	pagecard validate [flags] -file <path> [url]
+//
+// Run a command with -h to see its flags.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// command runs a subcommand with its arguments, reading its input from
+// stdin and writing its output to stdout.
+type command func(args []string, stdin io.Reader, stdout io.Writer) error
+
+var commands = map[string]command{
+	"inspect":  inspect,
+	"validate": validateCmd,
+}
+
+// errUsage is returned when a command is run with invalid arguments. Its
+// usage is already printed when it is returned.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "pagecard: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	switch err := cmd(os.Args[2:], os.Stdin, os.Stdout); err {
+	case nil:
+	case flag.ErrHelp:
+		os.Exit(0)
+	case errUsage:
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "pagecard: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: pagecard <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", name)
+	}
+}
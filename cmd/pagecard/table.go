@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mvader/pagecard"
+)
+
+// writeTable writes every value of the Info that is set in a row, named by
+// its path in the Info.
+func writeTable(w io.Writer, info *pagecard.Info) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tVALUE")
+	for _, row := range flatten(nil, "", reflect.ValueOf(info)) {
+		fmt.Fprintf(tw, "%s\t%s\n", row[0], row[1])
+	}
+	return tw.Flush()
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// flatten appends a row for every non-zero value in v, named by its path
+// from the given one. Struct fields are named after the field, slice
+// elements after their index and map values after their key. The fields of
+// embedded structs are named as if they were fields of the outer struct,
+// unless their names are ambiguous.
+func flatten(rows [][2]string, path string, v reflect.Value) [][2]string {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return rows
+		}
+		return flatten(rows, path, v.Elem())
+	}
+
+	if v.Type().Implements(stringerType) && v.Kind() != reflect.Struct {
+		if s := v.Interface().(fmt.Stringer).String(); s != "" {
+			rows = append(rows, [2]string{path, s})
+		}
+		return rows
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		promoted := promotedFields(v.Type())
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+
+			name := join(path, f.Name)
+			if promoted[i] {
+				name = path
+			}
+			rows = flatten(rows, name, v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			rows = flatten(rows, fmt.Sprintf("%s[%d]", path, i), v.Index(i))
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, k := range keys {
+			rows = flatten(rows, join(path, fmt.Sprint(k)), v.MapIndex(k))
+		}
+	default:
+		if !v.IsZero() {
+			value := strings.Join(strings.Fields(fmt.Sprint(v.Interface())), " ")
+			rows = append(rows, [2]string{path, value})
+		}
+	}
+
+	return rows
+}
+
+// promotedFields returns which embedded fields of the struct type have
+// fields whose names are not used by any other field.
+func promotedFields(t reflect.Type) map[int]bool {
+	var (
+		counts   = make(map[string]int)
+		embedded = make(map[int][]string)
+	)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if !f.Anonymous || ft.Kind() != reflect.Struct {
+			counts[f.Name]++
+			continue
+		}
+
+		for j := 0; j < ft.NumField(); j++ {
+			name := ft.Field(j).Name
+			embedded[i] = append(embedded[i], name)
+			counts[name]++
+		}
+	}
+
+	promoted := make(map[int]bool)
+	for i, names := range embedded {
+		promoted[i] = true
+		for _, name := range names {
+			if counts[name] > 1 {
+				promoted[i] = false
+			}
+		}
+	}
+	return promoted
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/mvader/pagecard/validate"
+)
+
+// validateCmd checks the metatags of a webpage, fetched from its URL or
+// read from a file, and fails if any of its issues is an error.
+func validateCmd(args []string, stdin io.Reader, stdout io.Writer) error {
+	var in input
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	in.register(fs)
+	format := fs.String("format", "table", "output format: table or json")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pagecard validate [flags] <url>")
+		fmt.Fprintln(fs.Output(), "       pagecard validate [flags] -file <path> [url]")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+
+	if !in.validArgs(fs) {
+		fs.Usage()
+		return errUsage
+	}
+
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	doc, err := in.document(fs.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+
+	issues := validate.Check(doc.Meta)
+	if *format == "json" {
+		if issues == nil {
+			issues = []*validate.Issue{}
+		}
+		err = writeJSON(stdout, issues)
+	} else {
+		err = writeIssues(stdout, issues)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var errors int
+	for _, i := range issues {
+		if i.Severity == validate.Error {
+			errors++
+		}
+	}
+
+	if errors > 0 {
+		return fmt.Errorf("%d errors found", errors)
+	}
+	return nil
+}
+
+func writeIssues(w io.Writer, issues []*validate.Issue) error {
+	if len(issues) == 0 {
+		_, err := fmt.Fprintln(w, "no issues found")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEVERITY\tRULE\tPROPERTY\tMESSAGE")
+	for _, i := range issues {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", i.Severity, i.Rule, i.Property, i.Message)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validPage = `<head>
+<meta property="og:title" content="Foo">
+<meta property="og:type" content="website">
+<meta property="og:url" content="http://foo.bar/">
+<meta property="og:image" content="http://foo.bar/a.png">
+<meta property="og:image:width" content="1200">
+<meta property="og:image:height" content="630">
+<meta name="twitter:card" content="summary_large_image">
+</head>`
+
+func TestValidateTable(t *testing.T) {
+	var out bytes.Buffer
+	err := validateCmd([]string{"-file", "-"}, strings.NewReader(page), &out)
+
+	assert := assert.New(t)
+	assert.Equal(err.Error(), "2 errors found")
+	assert.Equal(strings.Split(out.String(), "\n"), []string{
+		"SEVERITY  RULE                   PROPERTY  MESSAGE",
+		"error     og-missing-required    og:type   required property og:type is missing",
+		"error     og-missing-required    og:url    required property og:url is missing",
+		"notice    og-image-size-missing  og:image  declare og:image:width and og:image:height so the image can be rendered before it is downloaded",
+		"",
+	})
+}
+
+func TestValidateJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, validPage)
+	}))
+	defer srv.Close()
+
+	assert := assert.New(t)
+	var out bytes.Buffer
+	assert.Nil(validateCmd([]string{"-format", "json", srv.URL}, nil, &out))
+	assert.Equal(out.String(), "[]\n")
+
+	out.Reset()
+	assert.Nil(validateCmd([]string{"-file", "-"}, strings.NewReader(validPage), &out))
+	assert.Equal(out.String(), "no issues found\n")
+
+	out.Reset()
+	assert.NotNil(validateCmd([]string{"-format", "json", "-file", "-"}, strings.NewReader(page), &out))
+
+	var issues []map[string]string
+	assert.Nil(json.Unmarshal(out.Bytes(), &issues))
+	assert.Equal(issues[0], map[string]string{
+		"rule":     "og-missing-required",
+		"severity": "error",
+		"property": "og:type",
+		"message":  "required property og:type is missing",
+	})
+}
@@ -0,0 +1,293 @@
+package content
+
+import (
+	"container/list"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the responses of the pages retrieved by a Fetcher, or other
+// data derived from them under keys that are not URLs. Entries may be
+// returned after they expire, so caches are free to keep them until they
+// need room for others.
+type Cache interface {
+	// Get returns the entry stored with the given key, if any.
+	Get(key string) (*CacheEntry, bool)
+	// Set stores the entry with the given key.
+	Set(key string, entry *CacheEntry)
+	// Delete removes the entry stored with the given key.
+	Delete(key string)
+}
+
+// CacheEntry is the response of a page stored in a Cache.
+type CacheEntry struct {
+	FetchInfo
+	// Body is the content of the page.
+	Body []byte
+	// Expires is the time after which the entry is no longer fresh.
+	Expires time.Time
+	// ETag is the value of the ETag header of the response, used to
+	// revalidate the entry once it expires.
+	ETag string
+	// LastModified is the value of the Last-Modified header of the
+	// response, used to revalidate the entry once it expires.
+	LastModified string
+	// Err is the message of the error that occurred retrieving the page,
+	// if the entry caches a failure.
+	Err string
+}
+
+// Fresh reports whether the entry has not expired at the given time.
+func (e *CacheEntry) Fresh(t time.Time) bool {
+	return t.Before(e.Expires)
+}
+
+// revalidatable reports whether the entry can be revalidated with a
+// conditional request once it expires.
+func (e *CacheEntry) revalidatable() bool {
+	return e.Err == "" && (e.ETag != "" || e.LastModified != "")
+}
+
+// size returns the approximate number of bytes used by the entry.
+func (e *CacheEntry) size() int64 {
+	n := len(e.Body) + len(e.URL) + len(e.Err) + len(e.ETag) + len(e.LastModified)
+	for _, h := range e.Hops {
+		n += len(h.URL) + len(h.Location)
+	}
+	return int64(n)
+}
+
+// err returns the error cached by the entry. The errors defined by this
+// package are returned as is, so they can be compared.
+func (e *CacheEntry) err() error {
+	for _, err := range []error{
+		ErrTooManyRedirects,
+		ErrInsecureRedirect,
+		ErrForbiddenAddress,
+		ErrDisallowedByRobots,
+	} {
+		if e.Err == err.Error() {
+			return err
+		}
+	}
+	return errors.New(e.Err)
+}
+
+// now returns the current time. It is a variable so tests can replace it.
+var now = time.Now
+
+// MemoryCache is an in-memory Cache that evicts the least recently used
+// entries when it holds more entries or bytes than its limits. It is safe
+// for concurrent use.
+type MemoryCache struct {
+	mut        sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type memoryItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache returns a MemoryCache that holds at most maxEntries
+// entries and maxBytes bytes. A zero limit means no limit.
+func NewMemoryCache(maxEntries int, maxBytes int64) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Get returns the entry stored with the given key, if any.
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+	return el.Value.(*memoryItem).entry, true
+}
+
+// Set stores the entry with the given key. Entries bigger than the byte
+// limit of the cache are not stored.
+func (c *MemoryCache) Set(key string, entry *CacheEntry) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.remove(key)
+	size := entry.size()
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&memoryItem{key, entry})
+	c.bytes += size
+
+	for (c.maxEntries > 0 && c.lru.Len() > c.maxEntries) ||
+		(c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.remove(c.lru.Back().Value.(*memoryItem).key)
+	}
+}
+
+// Delete removes the entry stored with the given key.
+func (c *MemoryCache) Delete(key string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.remove(key)
+}
+
+// Len returns the number of entries in the cache.
+func (c *MemoryCache) Len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.lru.Len()
+}
+
+func (c *MemoryCache) remove(key string) {
+	el, ok := c.entries[key]
+	if !ok {
+		return
+	}
+
+	c.lru.Remove(el)
+	delete(c.entries, key)
+	c.bytes -= el.Value.(*memoryItem).entry.size()
+}
+
+// NormalizeURL returns the URL in a canonical form, so URLs that point to
+// the same page are cached with the same key. The scheme and host are
+// lowercased, default ports and fragments removed and query parameters
+// sorted. If the URL cannot be parsed, it is returned as is.
+func NormalizeURL(rawurl string) string {
+	u, err := url.Parse(strings.TrimSpace(rawurl))
+	if err != nil || u.Host == "" {
+		return rawurl
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && strings.HasSuffix(u.Host, ":80")) ||
+		(u.Scheme == "https" && strings.HasSuffix(u.Host, ":443")) {
+		u.Host = u.Host[:strings.LastIndex(u.Host, ":")]
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.RawQuery != "" {
+		u.RawQuery = u.Query().Encode()
+	}
+
+	return u.String()
+}
+
+// cacheDirective is what the headers of a response allow to do with it.
+type cacheDirective byte
+
+const (
+	// cacheAllowed allows to cache the response for its ttl.
+	cacheAllowed cacheDirective = iota
+	// cacheRevalidate allows to store the response, but it must be
+	// revalidated every time it is used.
+	cacheRevalidate
+	// cacheForbidden does not allow to store the response.
+	cacheForbidden
+)
+
+// cacheTTL returns how long a response with the given headers can be
+// cached according to its Cache-Control and Expires headers, and what it
+// is allowed to do with it.
+func cacheTTL(h http.Header) (time.Duration, cacheDirective) {
+	var (
+		maxAge, sMaxAge = -1, -1
+		directive       = cacheAllowed
+	)
+
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		parts := strings.SplitN(strings.TrimSpace(d), "=", 2)
+		name := strings.ToLower(parts[0])
+		switch name {
+		case "no-store":
+			return 0, cacheForbidden
+		case "no-cache":
+			directive = cacheRevalidate
+		case "max-age", "s-maxage":
+			if len(parts) != 2 {
+				continue
+			}
+
+			n, err := strconv.Atoi(strings.Trim(parts[1], `"`))
+			if err != nil || n < 0 {
+				continue
+			}
+
+			if name == "max-age" {
+				maxAge = n
+			} else {
+				sMaxAge = n
+			}
+		}
+	}
+
+	if directive == cacheRevalidate {
+		return 0, directive
+	}
+
+	if sMaxAge >= 0 {
+		return time.Duration(sMaxAge) * time.Second, directive
+	}
+
+	if maxAge >= 0 {
+		return time.Duration(maxAge) * time.Second, directive
+	}
+
+	if expires := h.Get("Expires"); expires != "" {
+		exp, err := http.ParseTime(expires)
+		if err != nil {
+			return 0, directive
+		}
+
+		date, err := http.ParseTime(h.Get("Date"))
+		if err != nil {
+			date = now()
+		}
+
+		if ttl := exp.Sub(date); ttl > 0 {
+			return ttl, directive
+		}
+	}
+
+	return 0, directive
+}
+
+// clampTTL limits the ttl to the given bounds. A zero max means no upper
+// bound.
+func clampTTL(ttl, min, max time.Duration) time.Duration {
+	if max > 0 && ttl > max {
+		ttl = max
+	}
+
+	if ttl < min {
+		ttl = min
+	}
+
+	return ttl
+}
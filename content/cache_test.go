@@ -0,0 +1,229 @@
+package content
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	assert := assert.New(t)
+	c := NewMemoryCache(2, 0)
+
+	c.Set("a", &CacheEntry{Body: []byte("a")})
+	c.Set("b", &CacheEntry{Body: []byte("b")})
+	_, ok := c.Get("a")
+	assert.True(ok)
+
+	c.Set("c", &CacheEntry{Body: []byte("c")})
+	assert.Equal(c.Len(), 2)
+	_, ok = c.Get("b")
+	assert.False(ok)
+
+	e, ok := c.Get("a")
+	assert.True(ok)
+	assert.Equal(e.Body, []byte("a"))
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	assert.False(ok)
+	assert.Equal(c.Len(), 1)
+
+	c = NewMemoryCache(0, 10)
+	c.Set("a", &CacheEntry{Body: []byte("aaaa")})
+	c.Set("b", &CacheEntry{Body: []byte("bbbb")})
+	c.Set("c", &CacheEntry{Body: []byte("cccc")})
+	assert.Equal(c.Len(), 2)
+	_, ok = c.Get("a")
+	assert.False(ok)
+
+	c.Set("d", &CacheEntry{Body: []byte("ddddddddddd")})
+	_, ok = c.Get("d")
+	assert.False(ok)
+	assert.Equal(c.Len(), 2)
+
+	c.Set("b", &CacheEntry{Body: []byte("bbbbbbbbbb")})
+	assert.Equal(c.Len(), 1)
+	assert.Equal(c.bytes, int64(10))
+}
+
+func TestNormalizeURL(t *testing.T) {
+	cases := []struct {
+		url      string
+		expected string
+	}{
+		{"HTTP://Foo.Bar", "http://foo.bar/"},
+		{"http://foo.bar:80/baz#qux", "http://foo.bar/baz"},
+		{"https://foo.bar:443/baz?b=2&a=1", "https://foo.bar/baz?a=1&b=2"},
+		{"https://foo.bar:8443/Baz", "https://foo.bar:8443/Baz"},
+		{"foo", "foo"},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		assert.Equal(NormalizeURL(c.url), c.expected)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	date := time.Date(2016, 5, 1, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		headers   map[string]string
+		ttl       time.Duration
+		directive cacheDirective
+	}{
+		{nil, 0, cacheAllowed},
+		{map[string]string{"Cache-Control": "public, max-age=60"}, time.Minute, cacheAllowed},
+		{map[string]string{"Cache-Control": "max-age=60, s-maxage=120"}, 2 * time.Minute, cacheAllowed},
+		{map[string]string{"Cache-Control": "no-store"}, 0, cacheForbidden},
+		{map[string]string{"Cache-Control": "max-age=60, no-cache"}, 0, cacheRevalidate},
+		{map[string]string{
+			"Date":    date.Format(http.TimeFormat),
+			"Expires": date.Add(time.Hour).Format(http.TimeFormat),
+		}, time.Hour, cacheAllowed},
+		{map[string]string{
+			"Cache-Control": "max-age=10",
+			"Expires":       date.Add(time.Hour).Format(http.TimeFormat),
+		}, 10 * time.Second, cacheAllowed},
+		{map[string]string{"Expires": "0"}, 0, cacheAllowed},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		h := make(http.Header)
+		for k, v := range c.headers {
+			h.Set(k, v)
+		}
+
+		ttl, directive := cacheTTL(h)
+		assert.Equal(ttl, c.ttl, "%v", c.headers)
+		assert.Equal(directive, c.directive, "%v", c.headers)
+	}
+
+	assert.Equal(clampTTL(time.Second, time.Minute, time.Hour), time.Minute)
+	assert.Equal(clampTTL(2*time.Hour, time.Minute, time.Hour), time.Hour)
+	assert.Equal(clampTTL(2*time.Hour, time.Minute, 0), 2*time.Hour)
+}
+
+func TestFetchCache(t *testing.T) {
+	requests := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/max-age":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		fmt.Fprintf(w, `<meta property="og:title" content="%s %d">`, r.URL.Path, requests[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	current := time.Now()
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	assert := assert.New(t)
+	f := &Fetcher{Cache: NewMemoryCache(10, 0)}
+	fetch := func(path string) string {
+		doc, err := f.Fetch(srv.URL + path)
+		assert.Nil(err)
+		return doc.Meta[0].Value
+	}
+
+	assert.Equal(fetch("/max-age"), "/max-age 1")
+	assert.Equal(fetch("/max-age"), "/max-age 1")
+	assert.Equal(fetch("/max-age#foo"), "/max-age 1")
+
+	current = current.Add(2 * time.Minute)
+	assert.Equal(fetch("/max-age"), "/max-age 2")
+
+	assert.Equal(fetch("/none"), "/none 1")
+	assert.Equal(fetch("/none"), "/none 2")
+
+	f.CacheMinTTL = time.Minute
+	assert.Equal(fetch("/none"), "/none 3")
+	assert.Equal(fetch("/none"), "/none 3")
+	assert.Equal(fetch("/no-store"), "/no-store 1")
+	assert.Equal(fetch("/no-store"), "/no-store 2")
+
+	f.CacheMinTTL = 0
+	f.CacheMaxTTL = 30 * time.Second
+	current = current.Add(2 * time.Minute)
+	assert.Equal(fetch("/max-age"), "/max-age 3")
+	current = current.Add(40 * time.Second)
+	assert.Equal(fetch("/max-age"), "/max-age 4")
+}
+
+func TestFetchExpires(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/long":
+			w.Header().Set("Cache-Control", "max-age=600")
+		case "/short":
+			w.Header().Set("Cache-Control", "max-age=60")
+			fmt.Fprint(w, `<meta http-equiv="refresh" content="0;url=/long">`)
+			return
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		fmt.Fprint(w, `<meta property="og:title" content="Foo">`)
+	}))
+	defer srv.Close()
+
+	current := time.Now()
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	cases := []struct {
+		path    string
+		expires time.Time
+	}{
+		{"/long", current.Add(10 * time.Minute)},
+		{"/short", current.Add(time.Minute)},
+		{"/no-store", time.Time{}},
+		{"/none", time.Time{}},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		doc, err := DefaultFetcher.Fetch(srv.URL + c.path)
+		assert.Nil(err)
+		assert.Equal(doc.Expires, c.expires, c.path)
+	}
+}
+
+func TestFetchNegativeCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.String(), http.StatusFound)
+	}))
+	defer srv.Close()
+
+	current := time.Now()
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	assert := assert.New(t)
+	cache := NewMemoryCache(10, 0)
+	f := &Fetcher{Cache: cache, CacheNegativeTTL: time.Minute}
+
+	_, err := f.Fetch(srv.URL)
+	assert.Equal(err, ErrTooManyRedirects)
+
+	entry, ok := cache.Get(NormalizeURL(srv.URL))
+	assert.True(ok)
+	assert.Equal(entry.Err, ErrTooManyRedirects.Error())
+
+	srv.Close()
+	_, err = f.Fetch(srv.URL)
+	assert.Equal(err, ErrTooManyRedirects)
+
+	current = current.Add(2 * time.Minute)
+	_, err = f.Fetch(srv.URL)
+	assert.NotNil(err)
+	assert.NotEqual(err, ErrTooManyRedirects)
+}
@@ -0,0 +1,153 @@
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DefaultMaxStale is the time an expired entry that can be revalidated
+	// is kept by a DiskCache if it does not specify one.
+	DefaultMaxStale = 7 * 24 * time.Hour
+	// tmpMaxAge is the age after which a temporary file is considered left
+	// behind by a write that did not finish.
+	tmpMaxAge = time.Hour
+)
+
+// DiskCache is a Cache that stores every entry in a file of a directory, so
+// entries survive restarts. Entries that have expired and cannot be
+// revalidated, because they cache a failure or have no ETag or
+// Last-Modified, or that expired more than MaxStale ago, are removed when
+// they are read or pruned. It is safe for concurrent use, also by several
+// processes sharing the directory.
+type DiskCache struct {
+	// MaxStale is the time an expired entry that can be revalidated is
+	// kept. If zero, DefaultMaxStale is used.
+	MaxStale time.Duration
+
+	dir string
+}
+
+type diskItem struct {
+	Key   string
+	Entry *CacheEntry
+}
+
+// NewDiskCache returns a DiskCache that stores its entries in the given
+// directory, creating it if it does not exist.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get returns the entry stored with the given key, if any. Entries that
+// cannot be read are considered missing, and unusable entries are removed.
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	path := c.path(key)
+	item, ok := readDiskItem(path)
+	if !ok || item.Key != key {
+		return nil, false
+	}
+
+	if !c.usable(item.Entry) {
+		os.Remove(path)
+		return nil, false
+	}
+
+	return item.Entry, true
+}
+
+// Prune removes the entries that cannot be read or are no longer usable,
+// including the ones that are never requested again, and the temporary
+// files left behind by writes that did not finish.
+func (c *DiskCache) Prune() error {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if item, ok := readDiskItem(path); !ok || !c.usable(item.Entry) {
+			os.Remove(path)
+		}
+	}
+
+	tmps, err := filepath.Glob(filepath.Join(c.dir, ".tmp-*"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range tmps {
+		if fi, err := os.Stat(path); err == nil && now().Sub(fi.ModTime()) > tmpMaxAge {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
+
+// Set stores the entry with the given key. Failures writing the entry are
+// ignored, since they only mean the page will be requested again.
+func (c *DiskCache) Set(key string, entry *CacheEntry) {
+	data, err := json.Marshal(diskItem{key, entry})
+	if err != nil {
+		return
+	}
+
+	// The entry is written to a temporary file and then renamed, so readers
+	// never see a partially written entry.
+	f, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil || os.Rename(f.Name(), c.path(key)) != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// Delete removes the entry stored with the given key.
+func (c *DiskCache) Delete(key string) {
+	os.Remove(c.path(key))
+}
+
+func readDiskItem(path string) (*diskItem, bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var item diskItem
+	if err := json.Unmarshal(data, &item); err != nil || item.Entry == nil {
+		return nil, false
+	}
+	return &item, true
+}
+
+// usable reports whether the fetcher can still use the entry, because it
+// is fresh or can be revalidated and has not been stale for too long.
+func (c *DiskCache) usable(e *CacheEntry) bool {
+	maxStale := c.MaxStale
+	if maxStale <= 0 {
+		maxStale = DefaultMaxStale
+	}
+
+	return e.Fresh(now()) || (e.revalidatable() && e.Fresh(now().Add(-maxStale)))
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,196 @@
+package content
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskCache(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "pagecard")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	c, err := NewDiskCache(dir)
+	assert.Nil(err)
+
+	_, ok := c.Get("foo")
+	assert.False(ok)
+
+	expires := time.Date(2016, 5, 1, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return expires.Add(-time.Minute) }
+	defer func() { now = time.Now }()
+
+	entry := &CacheEntry{
+		FetchInfo: FetchInfo{
+			URL:    "http://foo.bar/baz",
+			Status: 200,
+			Hops:   []*Hop{{"http://foo.bar", 301, "/baz", HopHTTP}},
+		},
+		Body:    []byte("<html></html>"),
+		Expires: expires,
+		ETag:    `"etag"`,
+	}
+	c.Set("foo", entry)
+
+	c, err = NewDiskCache(dir)
+	assert.Nil(err)
+
+	e, ok := c.Get("foo")
+	assert.True(ok)
+	assert.Equal(e, entry)
+
+	c.Delete("foo")
+	_, ok = c.Get("foo")
+	assert.False(ok)
+
+	assert.Nil(ioutil.WriteFile(c.path("bar"), []byte("{"), 0644))
+	_, ok = c.Get("bar")
+	assert.False(ok)
+}
+
+func TestFetchRevalidate(t *testing.T) {
+	var full, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		switch r.URL.Path {
+		case "/etag":
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/last-modified":
+			w.Header().Set("Last-Modified", "Sun, 01 May 2016 10:00:00 GMT")
+			if r.Header.Get("If-Modified-Since") == "Sun, 01 May 2016 10:00:00 GMT" {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		full++
+		fmt.Fprintf(w, `<meta property="og:title" content="%s">`, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	current := time.Now()
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "pagecard")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	for _, path := range []string{"/etag", "/last-modified", "/none"} {
+		full, notModified = 0, 0
+		for i := 0; i < 3; i++ {
+			// A new cache is used every time to check entries survive
+			// restarts.
+			cache, err := NewDiskCache(dir)
+			assert.Nil(err)
+
+			doc, err := (&Fetcher{Cache: cache}).Fetch(srv.URL + path)
+			assert.Nil(err)
+			assert.Equal(doc.Meta[0].Value, path)
+			assert.Equal(doc.Status, http.StatusOK)
+
+			doc, err = (&Fetcher{Cache: cache}).Fetch(srv.URL + path)
+			assert.Nil(err)
+			assert.Equal(doc.Meta[0].Value, path)
+
+			current = current.Add(2 * time.Minute)
+		}
+
+		if path == "/none" {
+			assert.Equal(full, 3, path)
+			assert.Equal(notModified, 0, path)
+		} else {
+			assert.Equal(full, 1, path)
+			assert.Equal(notModified, 2, path)
+		}
+	}
+}
+
+func TestDiskCacheEviction(t *testing.T) {
+	current := time.Date(2016, 5, 1, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "pagecard")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	c, err := NewDiskCache(dir)
+	assert.Nil(err)
+
+	expires := current.Add(time.Minute)
+	entries := map[string]*CacheEntry{
+		"plain":    {Body: []byte("plain"), Expires: expires},
+		"etag":     {Body: []byte("etag"), Expires: expires, ETag: `"v1"`},
+		"modified": {Body: []byte("modified"), Expires: expires, LastModified: "Sun, 01 May 2016 10:00:00 GMT"},
+		"failure":  {Expires: expires, Err: "not found"},
+	}
+	for key, entry := range entries {
+		c.Set(key, entry)
+	}
+	assert.Nil(ioutil.WriteFile(c.path("corrupt"), []byte("{"), 0644))
+
+	// Temporary files are removed once they are old enough to have been
+	// left behind by a write that did not finish.
+	oldTmp, newTmp := filepath.Join(dir, ".tmp-old"), filepath.Join(dir, ".tmp-new")
+	for _, path := range []string{oldTmp, newTmp} {
+		assert.Nil(ioutil.WriteFile(path, []byte("{"), 0644))
+	}
+	assert.Nil(os.Chtimes(oldTmp, current.Add(-2*time.Hour), current.Add(-2*time.Hour)))
+	assert.Nil(os.Chtimes(newTmp, current, current))
+
+	for key := range entries {
+		_, ok := c.Get(key)
+		assert.True(ok, key)
+	}
+
+	current = current.Add(2 * time.Minute)
+
+	_, ok := c.Get("plain")
+	assert.False(ok)
+	_, err = os.Stat(c.path("plain"))
+	assert.True(os.IsNotExist(err))
+
+	assert.Nil(c.Prune())
+	for _, path := range []string{c.path("failure"), c.path("corrupt"), oldTmp} {
+		_, err = os.Stat(path)
+		assert.True(os.IsNotExist(err), path)
+	}
+
+	_, err = os.Stat(newTmp)
+	assert.Nil(err)
+
+	for _, key := range []string{"etag", "modified"} {
+		e, ok := c.Get(key)
+		assert.True(ok, key)
+		assert.Equal(e, entries[key], key)
+	}
+
+	// Entries that can be revalidated are removed once they have been
+	// stale for longer than MaxStale.
+	c.MaxStale = time.Hour
+	current = current.Add(time.Hour)
+	_, ok = c.Get("etag")
+	assert.False(ok)
+
+	assert.Nil(c.Prune())
+	_, err = os.Stat(c.path("modified"))
+	assert.True(os.IsNotExist(err))
+}
@@ -0,0 +1,665 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// FetchInfo describes how a document was retrieved.
+type FetchInfo struct {
+	// URL is the final URL the document was read from.
+	URL string `json:"url"`
+	// Status is the HTTP status code of the response of the final URL.
+	Status int `json:"status,omitempty"`
+	// Hops contains all the redirections followed to reach the final URL,
+	// in the order they were followed.
+	Hops []*Hop `json:"hops,omitempty"`
+	// Attempts is the number of requests made to retrieve the final URL,
+	// including retries. It is zero if the page was retrieved from the
+	// cache of the fetcher.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// Hop is a redirection followed while fetching a page.
+type Hop struct {
+	// URL is the URL of the page that redirected.
+	URL string `json:"url"`
+	// Status is the HTTP status code of the page that redirected.
+	Status int `json:"status"`
+	// Location is the URL the page redirected to. For HTTP redirections it
+	// is the value of the Location header, which may be relative.
+	Location string `json:"location"`
+	// Kind is the mechanism used by the page to redirect.
+	Kind HopKind `json:"kind"`
+}
+
+// HopKind is the mechanism used by a page to redirect to another one.
+type HopKind byte
+
+const (
+	// HopRefresh is a redirection with a metatag like
+	// <meta http-equiv="refresh" content="0;url=...">.
+	HopRefresh HopKind = 1 << iota
+	// HopCanonical is a redirection to the canonical link of an
+	// interstitial page.
+	HopCanonical
+	// HopHTTP is a redirection with an HTTP 3xx response.
+	HopHTTP
+)
+
+var hopKindNames = map[HopKind]string{
+	HopRefresh:   "refresh",
+	HopCanonical: "canonical",
+	HopHTTP:      "http",
+}
+
+// String returns the name of the kind of redirection.
+func (k HopKind) String() string {
+	return hopKindNames[k]
+}
+
+// MarshalText encodes the kind of redirection as its name.
+func (k HopKind) MarshalText() ([]byte, error) {
+	name, ok := hopKindNames[k]
+	if !ok {
+		return nil, fmt.Errorf("content: invalid hop kind: %d", k)
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText decodes a kind of redirection from its name.
+func (k *HopKind) UnmarshalText(text []byte) error {
+	for kind, name := range hopKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("content: invalid hop kind: %s", text)
+}
+
+var (
+	// ErrTooManyRedirects is returned when a page makes more HTTP
+	// redirections than the maximum allowed by the fetcher.
+	ErrTooManyRedirects = errors.New("content: stopped after too many redirects")
+	// ErrInsecureRedirect is returned when a page served over https
+	// redirects to a page served over http and the fetcher does not allow
+	// downgrades.
+	ErrInsecureRedirect = errors.New("content: refusing to redirect from https to http")
+)
+
+// DefaultMaxRedirects is the maximum number of HTTP redirections followed
+// if the fetcher does not specify one.
+const DefaultMaxRedirects = 10
+
+// Fetcher retrieves webpages and reads their content.
+type Fetcher struct {
+	// Client is the HTTP client used to make the requests. If nil, a
+	// default client is used. Its CheckRedirect policy, if any, is applied
+	// after the ones of the fetcher.
+	Client *http.Client
+	// MaxRedirects is the maximum number of HTTP redirections followed for
+	// every page fetched. If zero, DefaultMaxRedirects is used.
+	MaxRedirects int
+	// AllowDowngrade allows redirections from https to http URLs.
+	// Otherwise, such HTTP redirections fail with ErrInsecureRedirect and
+	// such meta refresh and canonical redirections are not followed.
+	AllowDowngrade bool
+	// Scope is the part of the documents scanned for metatags.
+	Scope Scope
+	// MaxRefreshes is the maximum number of meta refresh or canonical
+	// redirections that will be followed. They are only followed from
+	// interstitial pages, that is, pages without OpenGraph or twitter
+	// metatags. If zero, they are not followed.
+	MaxRefreshes int
+	// MaxRefreshDelay is the maximum delay a meta refresh can have to be
+	// followed, so pages that reload themselves periodically are not
+	// considered redirections.
+	MaxRefreshDelay time.Duration
+	// FollowCanonical makes the fetcher follow the canonical link of
+	// interstitial pages.
+	FollowCanonical bool
+	// Cache stores the pages retrieved, so they are not requested again
+	// while they are fresh. Stale pages with an ETag or Last-Modified
+	// header are revalidated with a conditional request, and reused if
+	// they did not change. If nil, pages are not cached.
+	Cache Cache
+	// CacheMinTTL is the minimum time a page is cached, even if its
+	// Cache-Control or Expires headers allow less. Pages with no-store are
+	// never cached and pages with no-cache are always revalidated.
+	CacheMinTTL time.Duration
+	// CacheMaxTTL is the maximum time a page is cached. If zero, pages are
+	// cached as long as their headers allow.
+	CacheMaxTTL time.Duration
+	// CacheNegativeTTL is the time a failure retrieving a page is cached.
+	// If zero, failures are not cached.
+	CacheNegativeTTL time.Duration
+	// Retry is the policy to retry requests that fail with a transient
+	// error. If nil, requests are not retried.
+	Retry *RetryPolicy
+	// Robots checks the pages can be retrieved according to the
+	// robots.txt of their hosts before requesting them, including the
+	// targets of redirections. If nil, robots.txt is not checked.
+	Robots *Robots
+	// MaxBodyBytes is the maximum number of bytes of the body of a page
+	// that are read. Longer pages are truncated, which keeps their head
+	// for most pages. If zero, the whole body is read.
+	MaxBodyBytes int64
+	// UserAgent is the User-Agent header sent with the requests, whose
+	// product token is also the one looked up in robots.txt. If empty,
+	// the default of the client is sent.
+	UserAgent string
+
+	flight flightGroup
+}
+
+// DefaultFetcher is the Fetcher used when no other is given.
+var DefaultFetcher = &Fetcher{
+	MaxRefreshes:    5,
+	MaxRefreshDelay: 10 * time.Second,
+}
+
+const (
+	refreshName  = "refresh"
+	canonicalRel = "canonical"
+)
+
+// Fetch retrieves the page at the given URL, following its meta refresh
+// and canonical redirections as configured, and returns its document.
+// If the limit of redirections is reached, the last document fetched is
+// returned.
+func (f *Fetcher) Fetch(url string) (*Document, error) {
+	return f.FetchContext(context.Background(), url)
+}
+
+// FetchContext is like Fetch, but the request is canceled if the given
+// context is done before it finishes.
+//
+// Concurrent calls for the same URL share a single request, so the
+// returned document may be shared with other callers and must not be
+// modified. Every caller stops waiting when its own context is done, and
+// the shared request is canceled once no caller is waiting for it.
+func (f *Fetcher) FetchContext(ctx context.Context, url string) (*Document, error) {
+	return f.flight.do(ctx, NormalizeURL(url), func(ctx context.Context) (*Document, error) {
+		return f.fetchChain(ctx, url)
+	})
+}
+
+// fetchChain retrieves the page at the given URL, following its meta
+// refresh and canonical redirections. If a redirection cannot be fetched,
+// the last document fetched is returned, unless the redirection is to a
+// forbidden address or disallowed by robots.txt, or the context is done.
+func (f *Fetcher) fetchChain(ctx context.Context, url string) (*Document, error) {
+	var (
+		hops      []*Hop
+		refreshes int
+		visited   = map[string]bool{url: true}
+		prev      *Document
+	)
+
+	for {
+		doc, err := f.fetch(ctx, url)
+		if err != nil {
+			if prev == nil || ctx.Err() != nil {
+				return nil, err
+			}
+
+			switch err {
+			case ErrForbiddenAddress, ErrDisallowedByRobots:
+				return nil, err
+			}
+			return prev, nil
+		}
+
+		hops = append(hops, doc.Hops...)
+		doc.Hops = hops
+		// The document is only fresh while the pages that redirected to
+		// it are.
+		if prev != nil {
+			doc.Expires = earliest(prev.Expires, doc.Expires)
+		}
+		if refreshes >= f.MaxRefreshes {
+			return doc, nil
+		}
+
+		next, kind := f.redirection(doc)
+		if next == "" || visited[next] || f.downgradesTo(doc.URL, next) {
+			return doc, nil
+		}
+
+		hops = append(hops, &Hop{
+			URL:      doc.URL,
+			Status:   doc.Status,
+			Location: next,
+			Kind:     kind,
+		})
+		refreshes++
+		visited[next] = true
+		url = next
+		prev = doc
+	}
+}
+
+// fetch retrieves the page at the given URL, following its HTTP
+// redirections, from the cache of the fetcher or the network.
+func (f *Fetcher) fetch(ctx context.Context, rawurl string) (*Document, error) {
+	var (
+		key   string
+		stale *CacheEntry
+	)
+
+	if f.Cache != nil {
+		key = NormalizeURL(rawurl)
+		if entry, ok := f.Cache.Get(key); ok {
+			if entry.Fresh(now()) {
+				doc, err := f.document(entry)
+				if doc != nil {
+					doc.Attempts = 0
+				}
+				return doc, err
+			}
+
+			if entry.revalidatable() {
+				stale = entry
+			}
+		}
+	}
+
+	entry, cacheable, err := f.request(ctx, rawurl, stale)
+	if err != nil {
+		// Failures caused by the cancellation of the request are not
+		// failures of the page, so they are not cached.
+		if f.Cache != nil && f.CacheNegativeTTL > 0 && ctx.Err() == nil {
+			f.Cache.Set(key, &CacheEntry{
+				FetchInfo: FetchInfo{URL: rawurl},
+				Expires:   now().Add(f.CacheNegativeTTL),
+				Err:       err.Error(),
+			})
+		}
+		return nil, err
+	}
+
+	if f.Cache != nil && cacheable {
+		f.Cache.Set(key, entry)
+	}
+
+	doc, err := f.document(entry)
+	if doc != nil && !cacheable {
+		doc.Expires = time.Time{}
+	}
+	return doc, err
+}
+
+// document parses the document of the entry, or returns its error if the
+// entry is a cached failure.
+func (f *Fetcher) document(entry *CacheEntry) (*Document, error) {
+	if entry.Err != "" {
+		return nil, entry.err()
+	}
+
+	doc, err := ParseDocument(bytes.NewReader(entry.Body), f.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.FetchInfo = entry.FetchInfo
+	doc.Hops = append([]*Hop(nil), entry.Hops...)
+	doc.Expires = entry.Expires
+	doc.ETag = entry.ETag
+	doc.LastModified = entry.LastModified
+	return doc, nil
+}
+
+// request retrieves the page at the given URL from the network, retrying
+// it according to the retry policy of the fetcher, and returns it along
+// with whether it can be cached. If a stale cache entry is given, the
+// request is conditional on the page having changed since, and the entry
+// is reused if it did not.
+func (f *Fetcher) request(ctx context.Context, rawurl string, stale *CacheEntry) (*CacheEntry, bool, error) {
+	if err := f.checkRobots(ctx, rawurl); err != nil {
+		return nil, false, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		entry, cacheable, err := f.requestOnce(ctx, rawurl, stale)
+		if err == nil {
+			entry.Attempts = attempt
+			return entry, cacheable, nil
+		}
+
+		delay, ok := f.Retry.delay(attempt, err)
+		if !ok || ctx.Err() != nil {
+			if attempt > 1 {
+				err = &RetryError{Attempts: attempt, Err: err}
+			}
+			return nil, false, err
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// requestOnce makes a single request for the page at the given URL.
+func (f *Fetcher) requestOnce(ctx context.Context, rawurl string, stale *CacheEntry) (*CacheEntry, bool, error) {
+	var hops []*Hop
+	c := f.client(func(req *http.Request, via []*http.Request) error {
+		prev := via[len(via)-1]
+		hops = append(hops, &Hop{
+			URL:      prev.URL.String(),
+			Status:   req.Response.StatusCode,
+			Location: req.Response.Header.Get("Location"),
+			Kind:     HopHTTP,
+		})
+		return f.checkRedirect(req, via)
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawurl, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+
+	if stale != nil {
+		if stale.ETag != "" {
+			req.Header.Set("If-None-Match", stale.ETag)
+		}
+		if stale.LastModified != "" {
+			req.Header.Set("If-Modified-Since", stale.LastModified)
+		}
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok {
+			switch uerr.Err {
+			case ErrTooManyRedirects, ErrInsecureRedirect, ErrDisallowedByRobots:
+				return nil, false, uerr.Err
+			}
+		}
+		// The safe dialer fails inside the connection errors of the
+		// transport.
+		if errors.Is(err, ErrForbiddenAddress) {
+			return nil, false, ErrForbiddenAddress
+		}
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if f.Retry != nil && retryableStatus(resp.StatusCode) {
+		return nil, false, &StatusError{
+			Code:       resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	var entry *CacheEntry
+	if stale != nil && resp.StatusCode == http.StatusNotModified {
+		cp := *stale
+		entry = &cp
+	} else {
+		var r io.Reader = resp.Body
+		if f.MaxBodyBytes > 0 {
+			r = io.LimitReader(r, f.MaxBodyBytes)
+		}
+
+		body, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, false, err
+		}
+
+		entry = &CacheEntry{
+			FetchInfo: FetchInfo{
+				URL:    resp.Request.URL.String(),
+				Status: resp.StatusCode,
+				Hops:   hops,
+			},
+			Body: body,
+		}
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		entry.ETag = etag
+	}
+
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		entry.LastModified = lastModified
+	}
+
+	ttl, directive := cacheTTL(resp.Header)
+	if directive == cacheAllowed {
+		ttl = clampTTL(ttl, f.CacheMinTTL, f.CacheMaxTTL)
+	}
+	entry.Expires = now().Add(ttl)
+
+	cacheable := directive != cacheForbidden &&
+		(ttl > 0 || entry.ETag != "" || entry.LastModified != "")
+	return entry, cacheable, nil
+}
+
+// client returns a copy of the client of the fetcher that calls the given
+// function to check redirections.
+func (f *Fetcher) client(checkRedirect func(*http.Request, []*http.Request) error) *http.Client {
+	c := f.Client
+	if c == nil {
+		c = client
+	}
+
+	cp := *c
+	cp.CheckRedirect = checkRedirect
+	return &cp
+}
+
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	max := f.MaxRedirects
+	if max == 0 {
+		max = DefaultMaxRedirects
+	}
+
+	if len(via) > max {
+		return ErrTooManyRedirects
+	}
+
+	prev := via[len(via)-1]
+	if f.downgrades(prev.URL, req.URL) {
+		return ErrInsecureRedirect
+	}
+
+	if err := f.checkRobots(req.Context(), req.URL.String()); err != nil {
+		return err
+	}
+
+	c := f.Client
+	if c == nil {
+		c = client
+	}
+
+	if c.CheckRedirect != nil {
+		return c.CheckRedirect(req, via)
+	}
+	return nil
+}
+
+// downgrades reports whether a redirection between the given URLs goes
+// from https to http and the fetcher does not allow it.
+func (f *Fetcher) downgrades(from, to *url.URL) bool {
+	return !f.AllowDowngrade && from.Scheme == "https" && to.Scheme == "http"
+}
+
+// downgradesTo is like downgrades, for a meta refresh or canonical
+// redirection between the given absolute URLs.
+func (f *Fetcher) downgradesTo(from, to string) bool {
+	fu, err := url.Parse(from)
+	if err != nil {
+		return false
+	}
+
+	tu, err := url.Parse(to)
+	if err != nil {
+		return false
+	}
+
+	return f.downgrades(fu, tu)
+}
+
+// checkRobots checks the page at the given URL can be retrieved according
+// to the robots.txt of its host, if the fetcher checks it.
+func (f *Fetcher) checkRobots(ctx context.Context, rawurl string) error {
+	if f.Robots == nil {
+		return nil
+	}
+
+	return f.Robots.check(ctx, f.client(nil), f.UserAgent, rawurl)
+}
+
+// redirection returns the URL the document redirects to, if any. Only
+// interstitial pages are considered redirections, so pages with metatags
+// are not replaced by their fallbacks, and only http and https URLs are
+// followed. Meta refreshes inside noscript elements are followed too,
+// since they are how pages redirect clients without scripts.
+func (f *Fetcher) redirection(doc *Document) (string, HopKind) {
+	if !isInterstitial(doc) {
+		return "", 0
+	}
+
+	for _, n := range refreshNodes(doc.Root, f.Scope) {
+		delay, target, ok := parseRefresh(attrMap(n)[contentAttr])
+		if ok && delay <= f.MaxRefreshDelay {
+			if u := redirectionURL(doc.URL, target); u != "" {
+				return u, HopRefresh
+			}
+		}
+	}
+
+	if !f.FollowCanonical {
+		return "", 0
+	}
+
+	for _, l := range doc.Links {
+		if !l.HasRel(canonicalRel) {
+			continue
+		}
+
+		if u := redirectionURL(doc.URL, l.Href); u != "" {
+			return u, HopCanonical
+		}
+	}
+
+	return "", 0
+}
+
+// earliest returns the earliest of the given expiration times, or zero if
+// any of them is zero.
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || b.IsZero() {
+		return time.Time{}
+	}
+
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// refreshNodes returns the meta refresh elements in the scope of the
+// document.
+func refreshNodes(root *html.Node, scope Scope) []*html.Node {
+	if root == nil {
+		return nil
+	}
+
+	var nodes []*html.Node
+	for _, n := range extractNodes(root, "meta", scope) {
+		if strings.EqualFold(attrMap(n)[AttrHTTPEquiv], refreshName) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// redirectionURL returns the absolute URL of the target of a redirection
+// of the page at the given URL, or an empty string if it is not an http
+// or https URL or it is the page itself.
+func redirectionURL(base, target string) string {
+	u := resolve(base, target)
+	if u == "" || u == base {
+		return ""
+	}
+
+	if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	return u
+}
+
+// parseRefresh parses the content of a meta refresh, which has the form
+// "<delay>; url=<url>". The url may be quoted and the "url=" part omitted.
+func parseRefresh(content string) (time.Duration, string, bool) {
+	content = strings.TrimSpace(content)
+	idx := strings.IndexAny(content, ";,")
+	if idx < 0 {
+		return 0, "", false
+	}
+
+	secs, err := strconv.ParseFloat(strings.TrimSpace(content[:idx]), 64)
+	if err != nil || secs < 0 {
+		return 0, "", false
+	}
+
+	target := strings.TrimSpace(content[idx+1:])
+	if len(target) > 3 && strings.EqualFold(target[:3], "url") {
+		rest := strings.TrimSpace(target[3:])
+		if strings.HasPrefix(rest, "=") {
+			target = strings.TrimSpace(rest[1:])
+		}
+	}
+
+	target = strings.Trim(target, `'"`)
+	if target == "" {
+		return 0, "", false
+	}
+
+	return time.Duration(secs * float64(time.Second)), target, true
+}
+
+// isInterstitial reports whether the document has no OpenGraph or twitter
+// metatags.
+func isInterstitial(doc *Document) bool {
+	for _, m := range doc.Meta {
+		if strings.HasPrefix(m.Name, "og:") || strings.HasPrefix(m.Name, "twitter:") {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve returns the absolute URL of ref relative to base, or an empty
+// string if any of them is not a valid URL.
+func resolve(base, ref string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+
+	return b.ResolveReference(r).String()
+}
@@ -0,0 +1,254 @@
+package content
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(pages map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if loc := r.URL.Query().Get("redirect"); loc != "" {
+			http.Redirect(w, r, loc, http.StatusMovedPermanently)
+			return
+		}
+
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+}
+
+func TestFetchRefresh(t *testing.T) {
+	srv := newTestServer(map[string]string{
+		"/short":        `<head><meta http-equiv="refresh" content="0; URL='/landing'"></head>`,
+		"/landing":      `<head><meta property="og:title" content="Landing"></head>`,
+		"/slow":         `<head><meta http-equiv="Refresh" content="300;url=/landing"></head>`,
+		"/loop-a":       `<head><meta http-equiv="refresh" content="0;url=/loop-b"></head>`,
+		"/loop-b":       `<head><meta http-equiv="refresh" content="0;url=/loop-a"></head>`,
+		"/interstitial": `<head><link rel="canonical" href="/landing"></head>`,
+		"/article":      `<head><meta property="og:title" content="Article"><link rel="canonical" href="/landing"></head>`,
+		"/cards":        `<head><meta property="og:title" content="Cards"><meta http-equiv="refresh" content="0;url=/landing"></head>`,
+		"/noscript":     `<head><noscript><meta http-equiv="refresh" content="0;url=/landing"></noscript></head>`,
+		"/javascript":   `<head><meta http-equiv="refresh" content="0;url=javascript:alert(1)"></head>`,
+		"/unreachable":  `<head><meta http-equiv="refresh" content="0;url=http://127.0.0.1:1/"></head>`,
+	})
+	defer srv.Close()
+
+	cases := []struct {
+		fetcher *Fetcher
+		path    string
+		final   string
+		hops    []*Hop
+	}{
+		{&Fetcher{}, "/short", "/short", nil},
+		{DefaultFetcher, "/short", "/landing", []*Hop{
+			{srv.URL + "/short", 200, srv.URL + "/landing", HopRefresh},
+		}},
+		{DefaultFetcher, "/slow", "/slow", nil},
+		{&Fetcher{MaxRefreshes: 5, MaxRefreshDelay: 5 * time.Minute}, "/slow", "/landing", []*Hop{
+			{srv.URL + "/slow", 200, srv.URL + "/landing", HopRefresh},
+		}},
+		{DefaultFetcher, "/loop-a", "/loop-b", []*Hop{
+			{srv.URL + "/loop-a", 200, srv.URL + "/loop-b", HopRefresh},
+		}},
+		{&Fetcher{MaxRefreshes: 1}, "/loop-a", "/loop-b", []*Hop{
+			{srv.URL + "/loop-a", 200, srv.URL + "/loop-b", HopRefresh},
+		}},
+		{DefaultFetcher, "/interstitial", "/interstitial", nil},
+		{&Fetcher{MaxRefreshes: 1, FollowCanonical: true}, "/interstitial", "/landing", []*Hop{
+			{srv.URL + "/interstitial", 200, srv.URL + "/landing", HopCanonical},
+		}},
+		{&Fetcher{MaxRefreshes: 1, FollowCanonical: true}, "/article", "/article", nil},
+		{DefaultFetcher, "/cards", "/cards", nil},
+		{DefaultFetcher, "/noscript", "/landing", []*Hop{
+			{srv.URL + "/noscript", 200, srv.URL + "/landing", HopRefresh},
+		}},
+		{DefaultFetcher, "/javascript", "/javascript", nil},
+		{DefaultFetcher, "/unreachable", "/unreachable", nil},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		doc, err := c.fetcher.Fetch(srv.URL + c.path)
+		assert.Nil(err)
+		assert.Equal(doc.URL, srv.URL+c.final, c.path)
+		assert.Equal(doc.Hops, c.hops, c.path)
+	}
+}
+
+func TestFetchRefreshDisallowed(t *testing.T) {
+	srv := newTestServer(map[string]string{
+		"/robots.txt": "User-agent: *\nDisallow: /private\n",
+		"/refresh":    `<head><meta http-equiv="refresh" content="0;url=/private"></head>`,
+		"/private":    `<head><meta property="og:title" content="Private"></head>`,
+	})
+	defer srv.Close()
+
+	assert := assert.New(t)
+	f := &Fetcher{MaxRefreshes: 1, Robots: new(Robots)}
+	doc, err := f.Fetch(srv.URL + "/refresh")
+	assert.Nil(doc)
+	assert.Equal(err, ErrDisallowedByRobots)
+}
+
+func TestFetchHTTPRedirects(t *testing.T) {
+	srv := newTestServer(map[string]string{
+		"/short":   `<head><meta http-equiv="refresh" content="0;url=/moved?redirect=/landing"></head>`,
+		"/landing": `<head><meta property="og:title" content="Landing"></head>`,
+	})
+	defer srv.Close()
+
+	assert := assert.New(t)
+	doc, err := DefaultFetcher.Fetch(srv.URL + "/moved?redirect=/short")
+	assert.Nil(err)
+	assert.Equal(doc.FetchInfo, FetchInfo{
+		URL:    srv.URL + "/landing",
+		Status: 200,
+		Hops: []*Hop{
+			{srv.URL + "/moved?redirect=/short", 301, "/short", HopHTTP},
+			{srv.URL + "/short", 200, srv.URL + "/moved?redirect=/landing", HopRefresh},
+			{srv.URL + "/moved?redirect=/landing", 301, "/landing", HopHTTP},
+		},
+		Attempts: 1,
+	})
+	assert.Equal(doc.Meta[0].Value, "Landing")
+
+	chain := "/landing"
+	for i := 0; i < 3; i++ {
+		chain = "/moved?redirect=" + url.QueryEscape(chain)
+	}
+
+	_, err = (&Fetcher{MaxRedirects: 2}).Fetch(srv.URL + chain)
+	assert.Equal(err, ErrTooManyRedirects)
+
+	doc, err = (&Fetcher{MaxRedirects: 3}).Fetch(srv.URL + chain)
+	assert.Nil(err)
+	assert.Equal(doc.URL, srv.URL+"/landing")
+	assert.Equal(len(doc.Hops), 3)
+}
+
+func TestFetchDowngrade(t *testing.T) {
+	srv := newTestServer(map[string]string{
+		"/landing": `<head><meta property="og:title" content="Landing"></head>`,
+	})
+	defer srv.Close()
+
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, srv.URL+"/landing", http.StatusFound)
+	}))
+	defer tlsSrv.Close()
+
+	assert := assert.New(t)
+	_, err := (&Fetcher{Client: tlsSrv.Client()}).Fetch(tlsSrv.URL)
+	assert.Equal(err, ErrInsecureRedirect)
+
+	doc, err := (&Fetcher{Client: tlsSrv.Client(), AllowDowngrade: true}).Fetch(tlsSrv.URL)
+	assert.Nil(err)
+	assert.Equal(doc.URL, srv.URL+"/landing")
+	assert.Equal(doc.Hops, []*Hop{
+		{tlsSrv.URL, 302, srv.URL + "/landing", HopHTTP},
+	})
+}
+
+func TestFetchRefreshDowngrade(t *testing.T) {
+	srv := newTestServer(map[string]string{
+		"/landing": `<head><meta property="og:title" content="Landing"></head>`,
+	})
+	defer srv.Close()
+
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/refresh":
+			fmt.Fprintf(w, `<head><meta http-equiv="refresh" content="0;url=%s/landing"></head>`, srv.URL)
+		case "/canonical":
+			fmt.Fprintf(w, `<head><link rel="canonical" href="%s/landing"></head>`, srv.URL)
+		}
+	}))
+	defer tlsSrv.Close()
+
+	assert := assert.New(t)
+	for _, path := range []string{"/refresh", "/canonical"} {
+		f := &Fetcher{Client: tlsSrv.Client(), MaxRefreshes: 1, FollowCanonical: true}
+		doc, err := f.Fetch(tlsSrv.URL + path)
+		assert.Nil(err)
+		assert.Equal(doc.URL, tlsSrv.URL+path)
+		assert.Nil(doc.Hops)
+
+		f = &Fetcher{Client: tlsSrv.Client(), MaxRefreshes: 1, FollowCanonical: true, AllowDowngrade: true}
+		doc, err = f.Fetch(tlsSrv.URL + path)
+		assert.Nil(err)
+		assert.Equal(doc.URL, srv.URL+"/landing")
+		assert.Equal(len(doc.Hops), 1)
+	}
+}
+
+func TestParseRefresh(t *testing.T) {
+	cases := []struct {
+		content string
+		delay   time.Duration
+		url     string
+		ok      bool
+	}{
+		{"0;url=http://foo.bar", 0, "http://foo.bar", true},
+		{"0; URL = 'http://foo.bar'", 0, "http://foo.bar", true},
+		{`1.5, url="/baz"`, 1500 * time.Millisecond, "/baz", true},
+		{"3; /baz", 3 * time.Second, "/baz", true},
+		{"30", 0, "", false},
+		{"foo; url=/baz", 0, "", false},
+		{"0; url=", 0, "", false},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		delay, url, ok := parseRefresh(c.content)
+		assert.Equal(ok, c.ok, c.content)
+		assert.Equal(delay, c.delay, c.content)
+		assert.Equal(url, c.url, c.content)
+	}
+}
+
+func TestHopKindText(t *testing.T) {
+	assert := assert.New(t)
+	for _, kind := range []HopKind{HopRefresh, HopCanonical, HopHTTP} {
+		text, err := kind.MarshalText()
+		assert.Nil(err)
+		assert.Equal(string(text), kind.String())
+
+		var decoded HopKind
+		assert.Nil(decoded.UnmarshalText(text))
+		assert.Equal(decoded, kind)
+	}
+
+	_, err := HopKind(0).MarshalText()
+	assert.NotNil(err)
+
+	var kind HopKind
+	assert.NotNil(kind.UnmarshalText([]byte("teleport")))
+}
+
+func TestFetchMaxBodyBytes(t *testing.T) {
+	srv := newTestServer(map[string]string{
+		"/long": `<head><meta property="og:title" content="Long"></head><body>` + strings.Repeat("a", 1<<20) + `</body>`,
+	})
+	defer srv.Close()
+
+	assert := assert.New(t)
+	var cache = NewMemoryCache(10, 10<<20)
+	doc, err := (&Fetcher{MaxBodyBytes: 100, Cache: cache, CacheMinTTL: time.Minute}).Fetch(srv.URL + "/long")
+	assert.Nil(err)
+	assert.Equal(doc.Meta[0].Value, "Long")
+
+	entry, ok := cache.Get(NormalizeURL(srv.URL + "/long"))
+	assert.True(ok)
+	assert.Equal(len(entry.Body), 100)
+}
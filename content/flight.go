@@ -0,0 +1,79 @@
+package content
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup deduplicates concurrent fetches of the same page, so they
+// share a single request and parse.
+type flightGroup struct {
+	mut   sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall is a fetch in progress.
+type flightCall struct {
+	done    chan struct{}
+	doc     *Document
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do runs fn, unless there is already a call in progress for the given
+// key, in which case it waits for its result. The call runs with its own
+// context, which is canceled when all callers waiting for it are done.
+func (g *flightGroup) do(
+	ctx context.Context,
+	key string,
+	fn func(context.Context) (*Document, error),
+) (*Document, error) {
+	g.mut.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+
+	c, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.Background())
+		c = &flightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+
+		go func() {
+			c.doc, c.err = fn(callCtx)
+			g.forget(key, c)
+			cancel()
+			close(c.done)
+		}()
+	}
+	c.waiters++
+	g.mut.Unlock()
+
+	select {
+	case <-c.done:
+		return c.doc, c.err
+	case <-ctx.Done():
+		g.mut.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			g.forgetLocked(key, c)
+		}
+		g.mut.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// forget removes the call from the group, so new callers start a new one.
+func (g *flightGroup) forget(key string, c *flightCall) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	g.forgetLocked(key, c)
+}
+
+func (g *flightGroup) forgetLocked(key string, c *flightCall) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
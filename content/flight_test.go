@@ -0,0 +1,123 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchCoalescing(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		fmt.Fprint(w, `<meta property="og:title" content="Foo">`)
+	}))
+	defer srv.Close()
+
+	assert := assert.New(t)
+	f := &Fetcher{}
+
+	const callers = 10
+	var (
+		wg   sync.WaitGroup
+		docs = make([]*Document, callers)
+		errs = make([]error, callers)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := context.Background()
+			if i == 0 {
+				c = ctx
+			}
+			docs[i], errs[i] = f.FetchContext(c, srv.URL+"/#"+fmt.Sprint(i))
+		}(i)
+	}
+
+	waitFor(t, func() bool {
+		f.flight.mut.Lock()
+		defer f.flight.mut.Unlock()
+		for _, c := range f.flight.calls {
+			return c.waiters == callers
+		}
+		return false
+	})
+
+	cancel()
+	close(release)
+	wg.Wait()
+
+	assert.Equal(atomic.LoadInt32(&requests), int32(1))
+	assert.Equal(errs[0], context.Canceled)
+	assert.Nil(docs[0])
+	for i := 1; i < callers; i++ {
+		assert.Nil(errs[i])
+		assert.True(docs[i] == docs[1])
+	}
+	assert.Equal(docs[1].Meta[0].Value, "Foo")
+
+	doc, err := f.Fetch(srv.URL)
+	assert.Nil(err)
+	assert.False(doc == docs[1])
+	assert.Equal(atomic.LoadInt32(&requests), int32(2))
+}
+
+func TestFetchCoalescingCancel(t *testing.T) {
+	var (
+		once     sync.Once
+		canceled = make(chan struct{})
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		once.Do(func() { close(canceled) })
+	}))
+	defer srv.Close()
+
+	assert := assert.New(t)
+	f := &Fetcher{}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel2()
+
+	var wg sync.WaitGroup
+	for _, ctx := range []context.Context{ctx1, ctx2} {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			_, err := f.FetchContext(ctx, srv.URL)
+			assert.Equal(err, ctx.Err())
+		}(ctx)
+	}
+
+	cancel1()
+	wg.Wait()
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shared request was not canceled")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
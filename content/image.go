@@ -0,0 +1,171 @@
+package content
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+// ErrUnknownImageFormat is returned when the format of a probed image is
+// not PNG, JPEG, GIF or WebP.
+var ErrUnknownImageFormat = errors.New("unknown image format")
+
+// DefaultProbeBytes is the maximum number of bytes of an image read to
+// probe it if the prober does not specify one.
+const DefaultProbeBytes = 64 << 10
+
+// ImageProber retrieves the actual size and type of images by reading
+// only their first bytes. Its zero value is ready to use.
+type ImageProber struct {
+	// Client is used to request the images. If nil, http.DefaultClient is
+	// used. Use a client created with NewSafeClient if the URLs of the
+	// images come from untrusted webpages.
+	Client *http.Client
+	// MaxBytes is the maximum number of bytes of an image read to probe
+	// it. Images whose size cannot be found in their first MaxBytes bytes,
+	// such as JPEG images with large metadata, cannot be probed. If zero,
+	// DefaultProbeBytes is used.
+	MaxBytes int64
+	// UserAgent is the User-Agent header sent with the requests. If empty,
+	// the default one of net/http is sent.
+	UserAgent string
+}
+
+// ImageInfo is the actual size and type of an image.
+type ImageInfo struct {
+	// Type is the MIME type of the image.
+	Type   string
+	Width  int
+	Height int
+}
+
+type imageFormat struct {
+	mimeType     string
+	magic        string
+	decodeConfig func(io.Reader) (image.Config, error)
+}
+
+var imageFormats = []imageFormat{
+	{"image/png", "\x89PNG\r\n\x1a\n", png.DecodeConfig},
+	{"image/jpeg", "\xff\xd8", jpeg.DecodeConfig},
+	{"image/gif", "GIF8", gif.DecodeConfig},
+}
+
+// Probe returns the size and type of the image with the given URL. Only
+// its first bytes are requested, with a Range header, and read.
+func (p *ImageProber) Probe(ctx context.Context, url string) (*ImageInfo, error) {
+	maxBytes := p.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultProbeBytes
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", maxBytes-1))
+	if p.UserAgent != "" {
+		req.Header.Set("User-Agent", p.UserAgent)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, &StatusError{Code: resp.StatusCode}
+	}
+
+	return probeImage(io.LimitReader(resp.Body, maxBytes))
+}
+
+// probeImage reads the size and type of the image read from r.
+func probeImage(r io.Reader) (*ImageInfo, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(webpHeaderSize)
+
+	if isWebP(head) {
+		return probeWebP(head)
+	}
+
+	for _, f := range imageFormats {
+		if !bytes.HasPrefix(head, []byte(f.magic)) {
+			continue
+		}
+
+		cfg, err := f.decodeConfig(br)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ImageInfo{Type: f.mimeType, Width: cfg.Width, Height: cfg.Height}, nil
+	}
+
+	return nil, ErrUnknownImageFormat
+}
+
+// webpHeaderSize is the number of bytes of a WebP image that contain its
+// size: the RIFF header, the header of the first chunk and the first 10
+// bytes of its data.
+const webpHeaderSize = 30
+
+var errInvalidWebP = errors.New("invalid webp image")
+
+func isWebP(head []byte) bool {
+	return len(head) >= 16 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP"
+}
+
+// probeWebP reads the size of a WebP image from its header. The size is
+// stored in the first chunk, whose format depends on whether the image is
+// lossy (VP8), lossless (VP8L) or extended (VP8X).
+func probeWebP(head []byte) (*ImageInfo, error) {
+	if len(head) < webpHeaderSize {
+		return nil, errInvalidWebP
+	}
+
+	info := &ImageInfo{Type: "image/webp"}
+	data := head[20:]
+	switch string(head[12:16]) {
+	case "VP8 ":
+		if data[3] != 0x9d || data[4] != 0x01 || data[5] != 0x2a {
+			return nil, errInvalidWebP
+		}
+		info.Width = int(binary.LittleEndian.Uint16(data[6:]) & 0x3fff)
+		info.Height = int(binary.LittleEndian.Uint16(data[8:]) & 0x3fff)
+	case "VP8L":
+		if data[0] != 0x2f {
+			return nil, errInvalidWebP
+		}
+		bits := binary.LittleEndian.Uint32(data[1:])
+		info.Width = int(bits&0x3fff) + 1
+		info.Height = int(bits>>14&0x3fff) + 1
+	case "VP8X":
+		info.Width = int(uint24(data[4:])) + 1
+		info.Height = int(uint24(data[7:])) + 1
+	default:
+		return nil, errInvalidWebP
+	}
+
+	return info, nil
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
@@ -0,0 +1,96 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeImage(encode func(io.Writer, image.Image) error, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func webpImage(chunk string, data ...byte) []byte {
+	b := append([]byte("RIFF\x00\x00\x00\x00WEBP"+chunk+"\x0a\x00\x00\x00"), data...)
+	return append(b, make([]byte, 10)...)
+}
+
+func TestProbeImage(t *testing.T) {
+	cases := []struct {
+		data     []byte
+		expected *ImageInfo
+		err      bool
+	}{
+		{encodeImage(png.Encode, 300, 200), &ImageInfo{"image/png", 300, 200}, false},
+		{encodeImage(func(w io.Writer, m image.Image) error { return jpeg.Encode(w, m, nil) }, 640, 480), &ImageInfo{"image/jpeg", 640, 480}, false},
+		{encodeImage(func(w io.Writer, m image.Image) error { return gif.Encode(w, m, nil) }, 10, 20), &ImageInfo{"image/gif", 10, 20}, false},
+		{webpImage("VP8 ", 0, 0, 0, 0x9d, 0x01, 0x2a, 0x20, 0x03, 0x58, 0x02), &ImageInfo{"image/webp", 800, 600}, false},
+		{webpImage("VP8L", 0x2f, 0x1f, 0xc0, 0x1d, 0x00), &ImageInfo{"image/webp", 32, 120}, false},
+		{webpImage("VP8X", 0, 0, 0, 0, 0xff, 0x03, 0x00, 0x1f, 0x01, 0x00), &ImageInfo{"image/webp", 1024, 288}, false},
+		{webpImage("VP8 ", 0, 0, 0, 0, 0, 0), nil, true},
+		{webpImage("ALPH"), nil, true},
+		{[]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), nil, true},
+		{[]byte("<html></html>"), nil, true},
+		{[]byte("\x89PNG\r\n\x1a\n"), nil, true},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		info, err := probeImage(bytes.NewReader(c.data))
+		assert.Equal(err != nil, c.err)
+		assert.Equal(info, c.expected)
+	}
+}
+
+func TestImageProberProbe(t *testing.T) {
+	img := encodeImage(png.Encode, 300, 200)
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		switch r.URL.Path {
+		case "/partial.png":
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(img[:100])
+		case "/full.png":
+			w.Write(img)
+		case "/page":
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	assert := assert.New(t)
+	p := &ImageProber{MaxBytes: 100}
+	info, err := p.Probe(context.Background(), srv.URL+"/partial.png")
+	assert.Nil(err)
+	assert.Equal(info, &ImageInfo{"image/png", 300, 200})
+
+	info, err = p.Probe(context.Background(), srv.URL+"/full.png")
+	assert.Nil(err)
+	assert.Equal(info, &ImageInfo{"image/png", 300, 200})
+	assert.Equal(ranges, []string{"bytes=0-99", "bytes=0-99"})
+
+	_, err = p.Probe(context.Background(), srv.URL+"/page")
+	assert.Equal(err, ErrUnknownImageFormat)
+
+	_, err = p.Probe(context.Background(), srv.URL+"/missing.png")
+	assert.Equal(err, &StatusError{Code: http.StatusNotFound})
+
+	_, err = (&ImageProber{MaxBytes: 20}).Probe(context.Background(), srv.URL+"/full.png")
+	assert.NotNil(err)
+}
@@ -1,7 +1,10 @@
 package content
 
 import (
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"golang.org/x/net/html"
 )
@@ -10,76 +13,275 @@ import (
 type Meta struct {
 	Name  string
 	Value string
+	// Attr is the attribute the name of the metatag was read from. It is
+	// one of AttrProperty, AttrName, AttrItemprop, AttrHTTPEquiv or
+	// AttrCharset.
+	Attr string
+	// Attrs contains all the attributes of the metatag.
+	Attrs map[string]string
+}
+
+// Attributes of a metatag that give a name to its value.
+const (
+	AttrProperty  = "property"
+	AttrName      = "name"
+	AttrItemprop  = "itemprop"
+	AttrHTTPEquiv = "http-equiv"
+	AttrCharset   = "charset"
+)
+
+const (
+	contentAttr = "content"
+	valueAttr   = "value"
+)
+
+// nameAttrs are the attributes that can give a name to the content of a
+// metatag, in the order their metas are returned.
+var nameAttrs = []string{AttrProperty, AttrName, AttrItemprop, AttrHTTPEquiv}
+
+// IsNameOrProperty reports whether the name of the metatag was declared with
+// the name or property attributes, which are the ones used by protocols
+// such as OpenGraph or twitter cards. Metas built without an attribute are
+// considered to be so as well.
+func (m *Meta) IsNameOrProperty() bool {
+	return m.Attr == "" || m.Attr == AttrProperty || m.Attr == AttrName
+}
+
+// Scope is the part of the document that is scanned for metatags.
+type Scope byte
+
+const (
+	// ScopeHead only scans the head of the document, including the content
+	// of noscript elements.
+	ScopeHead Scope = iota
+	// ScopeHeadAndBody scans the head and the body of the document, for
+	// pages that place their metatags in the body.
+	ScopeHeadAndBody
+	// ScopeDocument scans the whole document, including the content of
+	// template elements.
+	ScopeDocument
+)
+
+// Document contains the data read from a webpage.
+type Document struct {
+	FetchInfo
+	// Meta contains the metatags of the document.
+	Meta []*Meta
+	// Links contains the link elements of the document.
+	Links []*Link
+	// Root is the root node of the parsed document.
+	Root *html.Node
+	// Expires is the time after which the document is no longer fresh,
+	// according to the cache headers of its responses and the TTL limits
+	// of the fetcher. It is zero if the document cannot be cached.
+	Expires time.Time
+	// ETag and LastModified are the values of the ETag and Last-Modified
+	// headers of the response of the final URL. A document with the same
+	// URL and the same non-empty values has the same content.
+	ETag         string
+	LastModified string
+}
+
+// Link represents a link element on the webpage.
+type Link struct {
+	Rel  string
+	Href string
+	Type string
+	// Attrs contains all the attributes of the link.
+	Attrs map[string]string
+}
+
+// HasRel reports whether the given relationship is one of the
+// space-separated relationships of the link, ignoring case.
+func (l *Link) HasRel(rel string) bool {
+	for _, r := range strings.Fields(l.Rel) {
+		if strings.EqualFold(r, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveURL returns the absolute URL of the given reference relative to
+// the URL of the document, or an empty string if it is not a valid URL.
+func (d *Document) ResolveURL(ref string) string {
+	return resolve(d.URL, ref)
+}
+
+// Elements returns all the elements of the document with the given tag,
+// in document order. The content of template elements is not included.
+func (d *Document) Elements(tag string) []*html.Node {
+	if d.Root == nil {
+		return nil
+	}
+	return findNodes(d.Root, tag, ScopeHeadAndBody)
 }
 
 var client = &http.Client{}
 
-// Read scans the page content at the given URL and returns a list of its metatags.
+// Read scans the head of the page content at the given URL and returns a
+// list of its metatags.
 func Read(url string) ([]*Meta, error) {
-	resp, err := client.Get(url)
+	return ReadScope(url, ScopeHead)
+}
+
+// ReadScope scans the given scope of the page content at the given URL and
+// returns a list of its metatags.
+func ReadScope(url string, scope Scope) ([]*Meta, error) {
+	doc, err := (&Fetcher{Scope: scope}).Fetch(url)
 	if err != nil {
 		return nil, err
 	}
 
-	node, err := html.Parse(resp.Body)
+	return doc.Meta, nil
+}
+
+// Parse reads an HTML document and returns the list of metatags found in
+// the given scope.
+func Parse(r io.Reader, scope Scope) ([]*Meta, error) {
+	doc, err := ParseDocument(r, scope)
 	if err != nil {
 		return nil, err
 	}
 
-	metatags := extractMetatags(node)
-	return metatagsToMetaList(metatags), nil
+	return doc.Meta, nil
 }
 
+// ParseDocument reads an HTML document and returns the metatags and links
+// found in the given scope.
+func ParseDocument(r io.Reader, scope Scope) (*Document, error) {
+	// Scripting is disabled so the content of noscript elements is parsed
+	// as markup instead of text.
+	node, err := html.ParseWithOptions(r, html.ParseOptionEnableScripting(false))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{
+		Meta:  metatagsToMetaList(extractNodes(node, "meta", scope)),
+		Links: linksToLinkList(extractNodes(node, "link", scope)),
+		Root:  node,
+	}, nil
+}
+
+// metatagsToMetaList converts the metatags to metas, one for every
+// distinct name of a metatag. Its content is read from the content
+// attribute or, if there is none, the value attribute of older markup.
 func metatagsToMetaList(metatags []*html.Node) []*Meta {
-	var (
-		result []*Meta
-		meta   *Meta
-	)
+	var result []*Meta
 
 	for _, m := range metatags {
-		meta = &Meta{}
-		for _, attr := range m.Attr {
-			switch attr.Key {
-			case "property", "name":
-				meta.Name = attr.Val
-			case "content":
-				meta.Value = attr.Val
-			}
+		attrs := attrMap(m)
+
+		if charset := attrs[AttrCharset]; charset != "" {
+			result = append(result, &Meta{
+				Name:  AttrCharset,
+				Value: charset,
+				Attr:  AttrCharset,
+				Attrs: attrs,
+			})
+		}
+
+		value := attrs[contentAttr]
+		if value == "" {
+			value = attrs[valueAttr]
+		}
+
+		if value == "" {
+			continue
 		}
 
-		if meta.Name != "" && meta.Value != "" {
-			result = append(result, meta)
+		var names []string
+		for _, key := range nameAttrs {
+			if name := attrs[key]; name != "" && !contains(names, name) {
+				names = append(names, name)
+				result = append(result, &Meta{
+					Name:  name,
+					Value: value,
+					Attr:  key,
+					Attrs: attrs,
+				})
+			}
 		}
 	}
 
 	return result
 }
 
-func extractMetatags(n *html.Node) []*html.Node {
-	if n.Type == html.ElementNode && n.Data == "head" {
-		return findMetaNodesInHead(n)
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
 	}
+	return false
+}
 
-	if n.Type != html.ElementNode && n.Type != html.DocumentNode {
-		return nil
+func linksToLinkList(links []*html.Node) []*Link {
+	var result []*Link
+	for _, l := range links {
+		attrs := attrMap(l)
+		if attrs["href"] == "" {
+			continue
+		}
+
+		result = append(result, &Link{
+			Rel:   attrs["rel"],
+			Href:  attrs["href"],
+			Type:  attrs["type"],
+			Attrs: attrs,
+		})
 	}
+	return result
+}
 
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		nodes := extractMetatags(c)
-		if len(nodes) > 0 {
-			return nodes
+// attrMap returns the attributes of the node. If an attribute is repeated,
+// the first value is kept.
+func attrMap(n *html.Node) map[string]string {
+	attrs := make(map[string]string, len(n.Attr))
+	for _, attr := range n.Attr {
+		if _, ok := attrs[attr.Key]; !ok {
+			attrs[attr.Key] = attr.Val
 		}
 	}
-
-	return nil
+	return attrs
 }
 
-func findMetaNodesInHead(n *html.Node) []*html.Node {
+// extractNodes returns the elements with the given tag in the scope.
+func extractNodes(n *html.Node, tag string, scope Scope) []*html.Node {
 	var nodes []*html.Node
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if c.Type == html.ElementNode && c.Data == "meta" {
-			nodes = append(nodes, c)
+		switch {
+		case scope == ScopeDocument:
+			nodes = append(nodes, findNodes(c, tag, scope)...)
+		case isElement(c, "html"):
+			nodes = append(nodes, extractNodes(c, tag, scope)...)
+		case isElement(c, "head"), scope == ScopeHeadAndBody && isElement(c, "body"):
+			nodes = append(nodes, findNodes(c, tag, scope)...)
 		}
 	}
 	return nodes
 }
+
+// findNodes returns all the elements with the given tag in the tree rooted
+// at n. The content of template elements is only scanned in ScopeDocument,
+// since it is not part of the rendered document.
+func findNodes(n *html.Node, tag string, scope Scope) []*html.Node {
+	if isElement(n, tag) {
+		return []*html.Node{n}
+	}
+
+	if isElement(n, "template") && scope != ScopeDocument {
+		return nil
+	}
+
+	var nodes []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, findNodes(c, tag, scope)...)
+	}
+	return nodes
+}
+
+func isElement(n *html.Node, tag string) bool {
+	return n.Type == html.ElementNode && n.Namespace == "" && n.Data == tag
+}
@@ -3,6 +3,9 @@ package content
 import (
 	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,6 +27,9 @@ const fixture = `
     <meta name="title" content="Foo title" />
     <meta name="description" content="Foo baz bar."
     />
+    <meta name="twitter:title" property="og:title" content="Baz title" />
+    <meta itemprop="name" content="Qux" />
+    <meta name="twitter:site" value="@foo" />
     <meta property="og:description" content="Foo bar baz."
     />
 </head>
@@ -50,21 +56,104 @@ func TestRead(t *testing.T) {
 	assert.Nil(err)
 
 	results := []Meta{
-		{"apple-itunes-app", "foo app"},
-		{"og:url", "foo url"},
-		{"theme-color", "#000000"},
-		{"og:image", "foo image"},
-		{"og:type", "foo type"},
-		{"og:title", "Bar title"},
-		{"og:site_name", "Foo"},
-		{"title", "Foo title"},
-		{"description", "Foo baz bar."},
-		{"og:description", "Foo bar baz."},
+		{Name: "charset", Value: "utf-8", Attr: AttrCharset},
+		{Name: "X-UA-Compatible", Value: "IE=edge", Attr: AttrHTTPEquiv},
+		{Name: "apple-itunes-app", Value: "foo app", Attr: AttrName},
+		{Name: "og:url", Value: "foo url", Attr: AttrProperty},
+		{Name: "theme-color", Value: "#000000", Attr: AttrName},
+		{Name: "og:image", Value: "foo image", Attr: AttrProperty},
+		{Name: "og:type", Value: "foo type", Attr: AttrProperty},
+		{Name: "og:title", Value: "Bar title", Attr: AttrProperty},
+		{Name: "og:site_name", Value: "Foo", Attr: AttrProperty},
+		{Name: "title", Value: "Foo title", Attr: AttrName},
+		{Name: "description", Value: "Foo baz bar.", Attr: AttrName},
+		{Name: "og:title", Value: "Baz title", Attr: AttrProperty},
+		{Name: "twitter:title", Value: "Baz title", Attr: AttrName},
+		{Name: "name", Value: "Qux", Attr: AttrItemprop},
+		{Name: "twitter:site", Value: "@foo", Attr: AttrName},
+		{Name: "og:description", Value: "Foo bar baz.", Attr: AttrProperty},
 	}
 
 	assert.Equal(len(metas), len(results))
 	for i, m := range metas {
 		assert.Equal(m.Name, results[i].Name)
 		assert.Equal(m.Value, results[i].Value)
+		assert.Equal(m.Attr, results[i].Attr)
 	}
+
+	assert.Equal(metas[11].Attrs, map[string]string{
+		"name":     "twitter:title",
+		"property": "og:title",
+		"content":  "Baz title",
+	})
+}
+
+func TestParseScope(t *testing.T) {
+	cases := []struct {
+		fixture string
+		scope   Scope
+		names   []string
+	}{
+		{"noscript.html", ScopeHead, []string{"charset", "og:title", "og:image"}},
+		{"body.html", ScopeHead, []string{"description"}},
+		{"body.html", ScopeHeadAndBody, []string{
+			"description", "og:title", "og:url", "og:type", "og:site_name",
+		}},
+		{"body.html", ScopeDocument, []string{
+			"description", "og:title", "og:url", "og:image", "og:type", "og:site_name",
+		}},
+		{"unclosed.html", ScopeHead, []string{"twitter:card"}},
+		{"unclosed.html", ScopeHeadAndBody, []string{"twitter:card", "twitter:title"}},
+		{"unclosed.html", ScopeDocument, []string{"twitter:card", "twitter:title", "twitter:site"}},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		f, err := os.Open(filepath.Join("testdata", c.fixture))
+		assert.Nil(err)
+
+		metas, err := Parse(f, c.scope)
+		f.Close()
+		assert.Nil(err)
+
+		var names []string
+		for _, m := range metas {
+			names = append(names, m.Name)
+		}
+		assert.Equal(names, c.names, "%s with scope %d", c.fixture, c.scope)
+	}
+}
+
+func TestDocumentElements(t *testing.T) {
+	assert := assert.New(t)
+	doc, err := ParseDocument(strings.NewReader(`<html><head><meta name="a" content="1"></head>
+<body><img src="a.png"><div><img src="b.png"></div><template><img src="c.png"></template></body></html>`), ScopeHead)
+	assert.Nil(err)
+
+	var srcs []string
+	for _, n := range doc.Elements("img") {
+		srcs = append(srcs, attrMap(n)["src"])
+	}
+	assert.Equal(srcs, []string{"a.png", "b.png"})
+	assert.Equal(len(doc.Elements("meta")), 1)
+	assert.Nil(new(Document).Elements("img"))
+}
+
+func TestParseDuplicateNames(t *testing.T) {
+	assert := assert.New(t)
+	metas, err := Parse(strings.NewReader(`<head>
+<meta property="og:image" name="og:image" content="a.png">
+<meta property="og:image:width" name="og:image:width" itemprop="width" content="200">
+</head>`), ScopeHead)
+	assert.Nil(err)
+
+	var result []Meta
+	for _, m := range metas {
+		result = append(result, Meta{Name: m.Name, Value: m.Value, Attr: m.Attr})
+	}
+	assert.Equal(result, []Meta{
+		{Name: "og:image", Value: "a.png", Attr: AttrProperty},
+		{Name: "og:image:width", Value: "200", Attr: AttrProperty},
+		{Name: "width", Value: "200", Attr: AttrItemprop},
+	})
 }
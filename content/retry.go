@@ -0,0 +1,149 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures how requests that fail with a transient error are
+// retried. Only timeouts and responses with status 429, 502, 503 and 504
+// are retried, since retrying them is safe and may succeed.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts made for a request,
+	// including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It is doubled for
+	// every subsequent retry, and a random jitter of up to half of it is
+	// subtracted so concurrent clients do not retry at once.
+	BaseDelay time.Duration
+	// MaxDelay is the maximum delay between two attempts. If a response
+	// asks with Retry-After to wait longer, the request is not retried.
+	// If zero, there is no maximum.
+	MaxDelay time.Duration
+}
+
+// StatusError is the error of a request whose response has a status that
+// may be retried.
+type StatusError struct {
+	Code int
+	// RetryAfter is the delay requested by the Retry-After header of the
+	// response, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("content: unexpected status code: %d", e.Code)
+}
+
+// RetryError is the error of a request that failed after being retried.
+type RetryError struct {
+	// Attempts is the number of attempts made.
+	Attempts int
+	// Err is the error of the last attempt.
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("content: giving up after %d attempts: %s", e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// delay returns the time to wait before retrying a request that failed
+// with the given error in the given attempt, and whether it must be
+// retried at all.
+func (p *RetryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	if p == nil || attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	switch err := err.(type) {
+	case *StatusError:
+		if err.RetryAfter > 0 {
+			if p.MaxDelay > 0 && err.RetryAfter > p.MaxDelay {
+				return 0, false
+			}
+			return err.RetryAfter, true
+		}
+	case net.Error:
+		if !err.Timeout() {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	d := p.BaseDelay << uint(attempt-1)
+	if d < p.BaseDelay || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+
+	if half := int64(d / 2); half > 0 {
+		d -= time.Duration(rand.Int63n(half + 1))
+	}
+
+	return d, true
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which can be a
+// number of seconds or an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0
+	}
+
+	if d := t.Sub(now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// sleep waits for the given duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,173 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyServer fails every request with the given status until the given
+// number of failures is reached.
+func flakyServer(failures int32, status int, retryAfter string) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`<meta property="og:title" content="Flaky">`))
+	}))
+	return srv, &requests
+}
+
+func TestFetchRetry(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	cases := []struct {
+		status   int
+		failures int32
+		retry    *RetryPolicy
+		attempts int
+		requests int32
+		ok       bool
+	}{
+		{http.StatusServiceUnavailable, 2, policy, 3, 3, true},
+		{http.StatusTooManyRequests, 1, policy, 2, 2, true},
+		{http.StatusBadGateway, 3, policy, 3, 3, false},
+		{http.StatusServiceUnavailable, 1, nil, 0, 1, true},
+		{http.StatusNotFound, 1, policy, 0, 1, true},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		srv, requests := flakyServer(c.failures, c.status, "")
+		doc, err := (&Fetcher{Retry: c.retry}).Fetch(srv.URL)
+		srv.Close()
+
+		assert.Equal(atomic.LoadInt32(requests), c.requests, c.status)
+		if c.ok {
+			assert.Nil(err, c.status)
+			if c.attempts > 0 {
+				assert.Equal(doc.Attempts, c.attempts, c.status)
+				assert.Equal(doc.Meta[0].Value, "Flaky", c.status)
+			}
+			continue
+		}
+
+		rerr, ok := err.(*RetryError)
+		if assert.True(ok, c.status) {
+			assert.Equal(rerr.Attempts, c.attempts)
+			assert.Equal(rerr.Err, &StatusError{Code: c.status})
+		}
+	}
+}
+
+func TestFetchRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	srv, requests := flakyServer(1, http.StatusServiceUnavailable, "1")
+	start := time.Now()
+	doc, err := (&Fetcher{Retry: &RetryPolicy{MaxAttempts: 2, MaxDelay: 2 * time.Second}}).Fetch(srv.URL)
+	srv.Close()
+	assert.Nil(err)
+	assert.Equal(doc.Attempts, 2)
+	assert.True(time.Since(start) >= time.Second)
+
+	srv, requests = flakyServer(1, http.StatusServiceUnavailable, "60")
+	_, err = (&Fetcher{Retry: &RetryPolicy{MaxAttempts: 2, MaxDelay: time.Second}}).Fetch(srv.URL)
+	srv.Close()
+	assert.Equal(err, &StatusError{Code: http.StatusServiceUnavailable, RetryAfter: time.Minute})
+	assert.Equal(atomic.LoadInt32(requests), int32(1))
+}
+
+func TestFetchRetryTimeout(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Write([]byte(`<meta property="og:title" content="Slow">`))
+	}))
+	defer srv.Close()
+
+	f := &Fetcher{
+		Client: &http.Client{Timeout: 20 * time.Millisecond},
+		Retry:  &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+	}
+
+	assert := assert.New(t)
+	doc, err := f.Fetch(srv.URL)
+	assert.Nil(err)
+	assert.Equal(doc.Attempts, 2)
+}
+
+func TestFetchRetryCanceled(t *testing.T) {
+	srv, _ := flakyServer(10, http.StatusServiceUnavailable, "")
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	f := &Fetcher{Retry: &RetryPolicy{MaxAttempts: 10, BaseDelay: time.Hour}}
+	_, err := f.FetchContext(ctx, srv.URL)
+	assert.Equal(t, err, context.DeadlineExceeded)
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	cases := []struct {
+		attempt  int
+		err      error
+		min, max time.Duration
+		ok       bool
+	}{
+		{1, &StatusError{Code: 503}, 50 * time.Millisecond, 100 * time.Millisecond, true},
+		{2, &StatusError{Code: 503}, 100 * time.Millisecond, 200 * time.Millisecond, true},
+		{4, &StatusError{Code: 503}, 150 * time.Millisecond, 300 * time.Millisecond, true},
+		{1, &StatusError{Code: 503, RetryAfter: 200 * time.Millisecond}, 200 * time.Millisecond, 200 * time.Millisecond, true},
+		{1, &StatusError{Code: 503, RetryAfter: time.Second}, 0, 0, false},
+		{5, &StatusError{Code: 503}, 0, 0, false},
+		{1, errors.New("connection refused"), 0, 0, false},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		d, ok := p.delay(c.attempt, c.err)
+		assert.Equal(ok, c.ok, c.attempt)
+		assert.True(d >= c.min && d <= c.max, d)
+	}
+
+	_, ok := (*RetryPolicy)(nil).delay(1, &StatusError{Code: 503})
+	assert.False(ok)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	current := time.Date(2016, 5, 1, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	cases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-1", 0},
+		{"Sun, 01 May 2016 10:00:30 GMT", 30 * time.Second},
+		{"Sun, 01 May 2016 09:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		assert.Equal(parseRetryAfter(c.value), c.expected, c.value)
+	}
+}
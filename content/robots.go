@@ -0,0 +1,375 @@
+package content
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowedByRobots is returned when the robots.txt of a host does not
+// allow the user agent of the fetcher to retrieve a page.
+var ErrDisallowedByRobots = errors.New("content: disallowed by robots.txt")
+
+const (
+	// DefaultRobotsTTL is the time the robots.txt of a host is cached if
+	// the checker does not specify one.
+	DefaultRobotsTTL = 24 * time.Hour
+	// DefaultMaxCrawlDelay is the maximum Crawl-delay honoured if the
+	// checker does not specify one.
+	DefaultMaxCrawlDelay = 10 * time.Second
+)
+
+const (
+	// robotsErrorTTL is the time a robots.txt that failed with a server
+	// error is cached, so it is retried sooner than valid ones.
+	robotsErrorTTL = time.Minute
+	// maxRobotsSize is the maximum number of bytes of a robots.txt read.
+	maxRobotsSize = 500 << 10
+)
+
+// Robots checks whether pages can be retrieved according to the robots.txt
+// of their hosts, and waits between requests to the same host as long as
+// its Crawl-delay asks. The rules of every host are cached. A robots.txt
+// that does not exist allows everything, and one that fails with a server
+// error disallows everything. The zero value is ready to use, and it is
+// safe for concurrent use.
+type Robots struct {
+	// TTL is the time the robots.txt of a host is cached. If zero,
+	// DefaultRobotsTTL is used.
+	TTL time.Duration
+	// MaxCrawlDelay is the maximum time waited between requests to the
+	// same host. Longer Crawl-delay values are reduced to it. If zero,
+	// DefaultMaxCrawlDelay is used.
+	MaxCrawlDelay time.Duration
+
+	mut   sync.Mutex
+	hosts map[string]*robotsHost
+}
+
+type robotsHost struct {
+	// sem is held while the robots.txt of the host is retrieved.
+	sem     chan struct{}
+	rules   *robotsRules
+	expires time.Time
+	next    time.Time
+}
+
+// check returns ErrDisallowedByRobots if the page at the given URL cannot
+// be retrieved by the user agent, and otherwise waits until the page can
+// be requested according to the Crawl-delay of its host.
+func (r *Robots) check(ctx context.Context, client *http.Client, userAgent, rawurl string) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+
+	h := r.host(strings.ToLower(u.Scheme + "://" + u.Host))
+	rules, err := r.rules(ctx, h, client, userAgent, u)
+	if err != nil {
+		return err
+	}
+
+	if u.EscapedPath() == "/robots.txt" {
+		return nil
+	}
+
+	group := rules.group(userAgent)
+	if !group.allowed(u.RequestURI()) {
+		return ErrDisallowedByRobots
+	}
+
+	return r.wait(ctx, h, group.crawlDelay)
+}
+
+func (r *Robots) host(key string) *robotsHost {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.hosts == nil {
+		r.hosts = make(map[string]*robotsHost)
+	}
+
+	h, ok := r.hosts[key]
+	if !ok {
+		h = &robotsHost{sem: make(chan struct{}, 1)}
+		r.hosts[key] = h
+	}
+	return h
+}
+
+// rules returns the rules of the host, retrieving its robots.txt if they
+// are not cached or expired.
+func (r *Robots) rules(
+	ctx context.Context,
+	h *robotsHost,
+	client *http.Client,
+	userAgent string,
+	u *url.URL,
+) (*robotsRules, error) {
+	select {
+	case h.sem <- struct{}{}:
+		defer func() { <-h.sem }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if h.rules != nil && now().Before(h.expires) {
+		return h.rules, nil
+	}
+
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	rules, ttl, err := fetchRobots(ctx, client, userAgent, robotsURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl == 0 {
+		ttl = r.TTL
+		if ttl == 0 {
+			ttl = DefaultRobotsTTL
+		}
+	}
+
+	h.rules, h.expires = rules, now().Add(ttl)
+	return rules, nil
+}
+
+// wait waits until a request can be made to the host, reserving that time
+// so the next request waits for the given delay.
+func (r *Robots) wait(ctx context.Context, h *robotsHost, delay time.Duration) error {
+	max := r.MaxCrawlDelay
+	if max == 0 {
+		max = DefaultMaxCrawlDelay
+	}
+
+	if delay > max {
+		delay = max
+	}
+
+	if delay <= 0 {
+		return nil
+	}
+
+	r.mut.Lock()
+	start := time.Now()
+	if h.next.After(start) {
+		start = h.next
+	}
+	h.next = start.Add(delay)
+	r.mut.Unlock()
+
+	return sleep(ctx, time.Until(start))
+}
+
+// fetchRobots retrieves and parses the robots.txt at the given URL. The
+// returned TTL is zero unless the rules must be cached for a specific
+// time.
+func fetchRobots(ctx context.Context, client *http.Client, userAgent, rawurl string) (*robotsRules, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawurl, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return disallowAll, robotsErrorTTL, nil
+	case resp.StatusCode >= 400:
+		return allowAll, 0, nil
+	case resp.StatusCode >= 300:
+		// Redirections were already followed by the client, so this one
+		// has no usable location.
+		return allowAll, 0, nil
+	}
+
+	rules, err := parseRobots(io.LimitReader(resp.Body, maxRobotsSize))
+	return rules, 0, err
+}
+
+// robotsRules are the rules of a robots.txt.
+type robotsRules struct {
+	groups []*robotsGroup
+}
+
+// robotsGroup are the rules of a robots.txt for some user agents.
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+var (
+	allowAll    = &robotsRules{}
+	disallowAll = &robotsRules{groups: []*robotsGroup{{
+		agents: []string{"*"},
+		rules:  []robotsRule{{false, "/"}},
+	}}}
+)
+
+// parseRobots parses a robots.txt. Unknown and malformed lines are
+// ignored.
+func parseRobots(r io.Reader) (*robotsRules, error) {
+	var (
+		rules    = new(robotsRules)
+		group    *robotsGroup
+		inAgents bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				group = new(robotsGroup)
+				rules.groups = append(rules.groups, group)
+				inAgents = true
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+			continue
+		case "allow", "disallow":
+			if group != nil && value != "" {
+				group.rules = append(group.rules, robotsRule{key == "allow", value})
+			}
+		case "crawl-delay":
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 && group != nil {
+				group.crawlDelay = time.Duration(secs * float64(time.Second))
+			}
+		default:
+			continue
+		}
+		inAgents = false
+	}
+
+	return rules, scanner.Err()
+}
+
+// group returns the rules for the given user agent, which are the ones of
+// the groups naming its product token or, if there are none, the ones of
+// the groups for all user agents.
+func (r *robotsRules) group(userAgent string) *robotsGroup {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	var specific, wildcard robotsGroup
+	for _, g := range r.groups {
+		if token != "" && g.names(token) {
+			specific.merge(g)
+		} else if g.names("*") {
+			wildcard.merge(g)
+		}
+	}
+
+	if specific.agents != nil {
+		return &specific
+	}
+	return &wildcard
+}
+
+// names reports whether the group is for the given user agent.
+func (g *robotsGroup) names(agent string) bool {
+	for _, a := range g.agents {
+		if a == agent {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *robotsGroup) merge(other *robotsGroup) {
+	g.agents = append(g.agents, other.agents...)
+	g.rules = append(g.rules, other.rules...)
+	if other.crawlDelay > g.crawlDelay {
+		g.crawlDelay = other.crawlDelay
+	}
+}
+
+// allowed reports whether the given path, including its query, is allowed
+// by the rules. The rule with the longest matching pattern applies, and
+// Allow rules win ties.
+func (g *robotsGroup) allowed(path string) bool {
+	allowed, longest := true, -1
+	for _, r := range g.rules {
+		if !matchRobots(r.pattern, path) {
+			continue
+		}
+
+		if n := len(r.pattern); n > longest || (n == longest && r.allow) {
+			allowed, longest = r.allow, n
+		}
+	}
+	return allowed
+}
+
+// matchRobots reports whether the path matches the pattern of a rule,
+// where * matches any sequence of characters and a trailing $ anchors the
+// pattern to the end of the path.
+func matchRobots(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	path = path[len(parts[0]):]
+
+	if len(parts) == 1 {
+		return !anchored || path == ""
+	}
+
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(path, part)
+		if i < 0 {
+			return false
+		}
+		path = path[i+len(part):]
+	}
+
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(path, last)
+	}
+	return strings.Contains(path, last)
+}
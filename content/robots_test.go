@@ -0,0 +1,188 @@
+package content
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRobots = `
+# Comments are ignored.
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Crawl-delay: 0.05
+
+User-agent: PageCardBot
+User-agent: other
+Disallow: /bot-only # trailing comment
+Disallow:
+
+user-agent: pagecardbot
+allow: /bot-only/ok
+`
+
+func TestRobotsAllowed(t *testing.T) {
+	rules, err := parseRobots(strings.NewReader(testRobots))
+	assert.Nil(t, err)
+
+	cases := []struct {
+		userAgent string
+		path      string
+		allowed   bool
+	}{
+		{"Go-http-client/1.1", "/", true},
+		{"Go-http-client/1.1", "/private", false},
+		{"Go-http-client/1.1", "/private/secret", false},
+		{"Go-http-client/1.1", "/private/public/page", true},
+		{"Go-http-client/1.1", "/doc.pdf", false},
+		{"Go-http-client/1.1", "/doc.pdf?download=1", true},
+		{"Go-http-client/1.1", "/bot-only", true},
+		{"PageCardBot/1.0 (+http://foo.bar)", "/private", true},
+		{"PageCardBot/1.0 (+http://foo.bar)", "/bot-only/page", false},
+		{"PageCardBot/1.0 (+http://foo.bar)", "/bot-only/ok", true},
+		{"", "/private", false},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		assert.Equal(rules.group(c.userAgent).allowed(c.path), c.allowed, c.userAgent+" "+c.path)
+	}
+
+	assert.Equal(rules.group("Go-http-client/1.1").crawlDelay, 50*time.Millisecond)
+	assert.Equal(rules.group("PageCardBot").crawlDelay, time.Duration(0))
+}
+
+func TestMatchRobots(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		matches bool
+	}{
+		{"/", "/foo", true},
+		{"/foo", "/foo/bar", true},
+		{"/foo", "/bar/foo", false},
+		{"/foo$", "/foo", true},
+		{"/foo$", "/foo/", false},
+		{"/*.php", "/index.php?a=b", true},
+		{"/*.php$", "/index.php?a=b", false},
+		{"/*/bar/*.gif$", "/foo/bar/baz.gif", true},
+		{"/*/bar/*.gif$", "/foo/baz/baz.gif", false},
+		{"*", "/anything", true},
+		{"/a*b*c", "/axxbxxc", true},
+		{"/a*b*c", "/axxcxxb", false},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		assert.Equal(matchRobots(c.pattern, c.path), c.matches, c.pattern+" "+c.path)
+	}
+}
+
+func newRobotsServer(status int, robots string) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			atomic.AddInt32(&requests, 1)
+			w.WriteHeader(status)
+			fmt.Fprint(w, robots)
+			return
+		}
+
+		if loc := r.URL.Query().Get("redirect"); loc != "" {
+			http.Redirect(w, r, loc, http.StatusMovedPermanently)
+			return
+		}
+
+		fmt.Fprintf(w, `<meta property="og:title" content="%s">`, r.UserAgent())
+	}))
+	return srv, &requests
+}
+
+func TestFetchRobots(t *testing.T) {
+	srv, requests := newRobotsServer(http.StatusOK, "User-agent: *\nDisallow: /private\n")
+	defer srv.Close()
+
+	f := &Fetcher{Robots: new(Robots), UserAgent: "PageCardBot/1.0"}
+	cases := []struct {
+		path string
+		err  error
+	}{
+		{"/public", nil},
+		{"/private", ErrDisallowedByRobots},
+		{"/public?redirect=/private", ErrDisallowedByRobots},
+		{"/public?redirect=/public", nil},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		doc, err := f.Fetch(srv.URL + c.path)
+		assert.Equal(err, c.err, c.path)
+		if err == nil {
+			assert.Equal(doc.Meta[0].Value, "PageCardBot/1.0", c.path)
+		}
+	}
+
+	assert.Equal(atomic.LoadInt32(requests), int32(1))
+}
+
+func TestFetchRobotsStatus(t *testing.T) {
+	cases := []struct {
+		status int
+		err    error
+	}{
+		{http.StatusNotFound, nil},
+		{http.StatusForbidden, nil},
+		{http.StatusInternalServerError, ErrDisallowedByRobots},
+		{http.StatusServiceUnavailable, ErrDisallowedByRobots},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		srv, _ := newRobotsServer(c.status, "User-agent: *\nDisallow: /\n")
+		_, err := (&Fetcher{Robots: new(Robots)}).Fetch(srv.URL + "/page")
+		srv.Close()
+		assert.Equal(err, c.err, c.status)
+	}
+}
+
+func TestFetchRobotsCrawlDelay(t *testing.T) {
+	srv, _ := newRobotsServer(http.StatusOK, "User-agent: *\nCrawl-delay: 60\n")
+	defer srv.Close()
+
+	f := &Fetcher{Robots: &Robots{MaxCrawlDelay: 50 * time.Millisecond}}
+
+	assert := assert.New(t)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := f.Fetch(fmt.Sprintf("%s/%d", srv.URL, i))
+		assert.Nil(err)
+	}
+	assert.True(time.Since(start) >= 100*time.Millisecond)
+}
+
+func TestRobotsTTL(t *testing.T) {
+	srv, requests := newRobotsServer(http.StatusOK, "")
+	defer srv.Close()
+
+	current := time.Now()
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	f := &Fetcher{Robots: &Robots{TTL: time.Hour}}
+
+	assert := assert.New(t)
+	for i := 0; i < 3; i++ {
+		_, err := f.Fetch(fmt.Sprintf("%s/%d", srv.URL, i))
+		assert.Nil(err)
+		current = current.Add(40 * time.Minute)
+	}
+	assert.Equal(atomic.LoadInt32(requests), int32(2))
+}
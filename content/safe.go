@@ -0,0 +1,206 @@
+package content
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a request is made to a URL or
+// network address forbidden by a SafePolicy.
+var ErrForbiddenAddress = errors.New("content: forbidden address")
+
+var (
+	// DefaultSafeSchemes are the URL schemes allowed by a SafePolicy that
+	// does not specify them.
+	DefaultSafeSchemes = []string{"http", "https"}
+	// DefaultSafePorts are the ports allowed by a SafePolicy that does not
+	// specify them.
+	DefaultSafePorts = []int{80, 443}
+)
+
+// forbiddenNetworks are the networks that are not reachable from the
+// internet, or that address the host itself.
+var forbiddenNetworks = parseNetworks(
+	"0.0.0.0/8",      // "this" network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier-grade NAT
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local
+	"172.16.0.0/12",  // private
+	"192.0.0.0/24",   // IETF protocol assignments
+	"192.168.0.0/16", // private
+	"198.18.0.0/15",  // benchmarking
+	"224.0.0.0/4",    // multicast
+	"240.0.0.0/4",    // reserved and broadcast
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"64:ff9b::/96",   // IPv4/IPv6 translation
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+	"ff00::/8",       // multicast
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// SafePolicy restricts the requests that can be made to fetch URLs given
+// by untrusted users, so they cannot be used to reach services of the
+// internal network of the host. Addresses are checked after their host
+// names are resolved, for every request made, including redirections.
+// Private, loopback, link-local and multicast addresses are forbidden.
+type SafePolicy struct {
+	// Schemes are the URL schemes that can be requested. If nil,
+	// DefaultSafeSchemes are allowed.
+	Schemes []string
+	// Ports are the ports that can be connected to. If nil,
+	// DefaultSafePorts are allowed.
+	Ports []int
+	// Allow are networks that can be connected to even if they are
+	// forbidden by default.
+	Allow []*net.IPNet
+	// Timeout is the maximum time a connection can take to be
+	// established. If zero, there is no timeout.
+	Timeout time.Duration
+}
+
+// NewSafeClient returns an HTTP client that only makes requests allowed by
+// the given policy, and fails with ErrForbiddenAddress otherwise. It does
+// not use proxies, since the addresses checked would be the ones of the
+// proxy. If p is nil, the default policy is used.
+func NewSafeClient(p *SafePolicy) *http.Client {
+	if p == nil {
+		p = new(SafePolicy)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = p.Dialer().DialContext
+
+	return &http.Client{Transport: &safeTransport{p, transport}}
+}
+
+// Dialer returns a dialer that only connects to addresses allowed by the
+// policy.
+func (p *SafePolicy) Dialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   p.Timeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return p.checkAddress(address)
+		},
+	}
+}
+
+// checkURL returns ErrForbiddenAddress if the scheme or port of the URL are
+// not allowed.
+func (p *SafePolicy) checkURL(u *url.URL) error {
+	schemes := p.Schemes
+	if schemes == nil {
+		schemes = DefaultSafeSchemes
+	}
+
+	if !containsFold(schemes, u.Scheme) {
+		return ErrForbiddenAddress
+	}
+
+	if port := u.Port(); port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || !p.allowedPort(n) {
+			return ErrForbiddenAddress
+		}
+	}
+
+	return nil
+}
+
+// checkAddress returns ErrForbiddenAddress if the resolved address, in the
+// host:port form, is not allowed.
+func (p *SafePolicy) checkAddress(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil || !p.allowedPort(n) {
+		return ErrForbiddenAddress
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !p.allowedIP(ip) {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
+
+func (p *SafePolicy) allowedPort(port int) bool {
+	ports := p.Ports
+	if ports == nil {
+		ports = DefaultSafePorts
+	}
+
+	for _, allowed := range ports {
+		if port == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *SafePolicy) allowedIP(ip net.IP) bool {
+	for _, n := range p.Allow {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	for _, n := range forbiddenNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// safeTransport checks the URL of every request against the policy before
+// sending it, since the dialer only sees the addresses.
+type safeTransport struct {
+	policy *SafePolicy
+	base   http.RoundTripper
+}
+
+func (t *safeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.checkURL(req.URL); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	return t.base.RoundTrip(req)
+}
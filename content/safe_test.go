@@ -0,0 +1,116 @@
+package content
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeClient(t *testing.T) {
+	srv := newTestServer(map[string]string{
+		"/landing": `<head><meta property="og:title" content="Landing"></head>`,
+		"/refresh": `<head><meta http-equiv="refresh" content="0;url=http://10.0.0.1/"></head>`,
+	})
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	_, loopback, _ := net.ParseCIDR("127.0.0.1/32")
+
+	allowed := &Fetcher{
+		MaxRefreshes: 1,
+		Client: NewSafeClient(&SafePolicy{
+			Ports: []int{port},
+			Allow: []*net.IPNet{loopback},
+		}),
+	}
+
+	cases := []struct {
+		fetcher *Fetcher
+		url     string
+		err     error
+	}{
+		{&Fetcher{Client: NewSafeClient(nil)}, srv.URL + "/landing", ErrForbiddenAddress},
+		{&Fetcher{Client: NewSafeClient(&SafePolicy{Ports: []int{port}})}, srv.URL + "/landing", ErrForbiddenAddress},
+		{allowed, srv.URL + "/landing", nil},
+		{allowed, srv.URL + "/landing?redirect=/landing", nil},
+		{allowed, srv.URL + "/?redirect=http://169.254.169.254:" + u.Port() + "/", ErrForbiddenAddress},
+		{allowed, srv.URL + "/?redirect=http://127.0.0.1:1/", ErrForbiddenAddress},
+		{allowed, srv.URL + "/?redirect=ftp://127.0.0.1/", ErrForbiddenAddress},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		doc, err := c.fetcher.Fetch(c.url)
+		assert.Equal(err, c.err, c.url)
+		if c.err == nil {
+			assert.Equal(doc.Meta[0].Value, "Landing", c.url)
+		}
+	}
+
+	// Refreshes to forbidden addresses fail like HTTP redirections.
+	doc, err := allowed.Fetch(srv.URL + "/refresh")
+	assert.Nil(doc)
+	assert.Equal(err, ErrForbiddenAddress)
+}
+
+func TestSafePolicyCheckAddress(t *testing.T) {
+	_, allowed, _ := net.ParseCIDR("10.1.0.0/16")
+	p := &SafePolicy{Allow: []*net.IPNet{allowed}}
+
+	cases := []struct {
+		address string
+		err     error
+	}{
+		{"93.184.216.34:80", nil},
+		{"93.184.216.34:443", nil},
+		{"93.184.216.34:8080", ErrForbiddenAddress},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", nil},
+		{"127.0.0.1:80", ErrForbiddenAddress},
+		{"10.0.0.1:80", ErrForbiddenAddress},
+		{"10.1.2.3:80", nil},
+		{"172.16.5.4:80", ErrForbiddenAddress},
+		{"192.168.1.1:80", ErrForbiddenAddress},
+		{"169.254.169.254:80", ErrForbiddenAddress},
+		{"0.0.0.0:80", ErrForbiddenAddress},
+		{"224.0.0.1:80", ErrForbiddenAddress},
+		{"[::1]:80", ErrForbiddenAddress},
+		{"[::ffff:127.0.0.1]:80", ErrForbiddenAddress},
+		{"[fe80::1]:80", ErrForbiddenAddress},
+		{"[fd00::1]:80", ErrForbiddenAddress},
+		{"[ff02::1]:80", ErrForbiddenAddress},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		assert.Equal(p.checkAddress(c.address), c.err, c.address)
+	}
+}
+
+func TestSafePolicyCheckURL(t *testing.T) {
+	cases := []struct {
+		url string
+		err error
+	}{
+		{"http://foo.bar/", nil},
+		{"HTTPS://foo.bar:443/", nil},
+		{"ftp://foo.bar/", ErrForbiddenAddress},
+		{"file:///etc/passwd", ErrForbiddenAddress},
+		{"http://foo.bar:22/", ErrForbiddenAddress},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		u, err := url.Parse(c.url)
+		assert.Nil(err)
+		assert.Equal(new(SafePolicy).checkURL(u), c.err, c.url)
+	}
+
+	req, _ := http.NewRequest("GET", "gopher://foo.bar/", nil)
+	_, err := NewSafeClient(nil).Do(req)
+	assert.NotNil(err)
+}
@@ -0,0 +1,134 @@
+package pagecard
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/opengraph"
+	"github.com/mvader/pagecard/twitter"
+)
+
+// Extractor retrieves data from the document of a webpage.
+type Extractor interface {
+	// Name returns the name of the extractor, which is the key of its data
+	// in Info.Extra.
+	Name() string
+	// Extract returns the data of the document. The document contains
+	// the information of how it was fetched, so extractors for specific
+	// sites can check its URL. If the returned data is nil, it is not
+	// added to the Info. The document may be shared with other
+	// extractors and callers, so it must not be modified.
+	Extract(doc *content.Document) (interface{}, error)
+}
+
+var registry = struct {
+	sync.RWMutex
+	extractors []Extractor
+}{
+	extractors: []Extractor{
+		opengraph.Extractor{},
+		twitter.Extractor{},
+	},
+}
+
+// Register adds an extractor to the ones run for every webpage. If there
+// is already an extractor with the same name, it is replaced.
+func Register(e Extractor) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for i, r := range registry.extractors {
+		if r.Name() == e.Name() {
+			registry.extractors[i] = e
+			return
+		}
+	}
+
+	registry.extractors = append(registry.extractors, e)
+}
+
+// Unregister removes the extractor with the given name.
+func Unregister(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for i, r := range registry.extractors {
+		if r.Name() == name {
+			registry.extractors = append(registry.extractors[:i], registry.extractors[i+1:]...)
+			return
+		}
+	}
+}
+
+// Extractors returns the registered extractors, in the order they are run.
+func Extractors() []Extractor {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return append([]Extractor(nil), registry.extractors...)
+}
+
+// selectExtractors returns the registered extractors with the given names.
+// If names is nil, all the registered extractors are returned.
+func selectExtractors(names []string) ([]Extractor, error) {
+	extractors := Extractors()
+	if names == nil {
+		return extractors, nil
+	}
+
+	byName := make(map[string]Extractor, len(extractors))
+	for _, e := range extractors {
+		byName[e.Name()] = e
+	}
+
+	var result []Extractor
+	for _, n := range names {
+		e, ok := byName[n]
+		if !ok {
+			return nil, fmt.Errorf("unknown extractor: %s", n)
+		}
+		result = append(result, e)
+	}
+
+	return result, nil
+}
+
+// extract builds the Info of the document with the given extractors.
+func extract(doc *content.Document, extractors []Extractor) (*Info, error) {
+	// The document may be shared with other callers fetching the same
+	// page, so its fetch info is copied.
+	fetch := doc.FetchInfo
+	info := &Info{Fetch: &fetch}
+	for _, e := range extractors {
+		data, err := e.Extract(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		if data == nil {
+			continue
+		}
+
+		// The data of the builtin extractors has its own field, and the
+		// data of any other extractor goes to Extra, whatever its type.
+		obj, isObject := data.(*opengraph.Object)
+		card, isCard := data.(*twitter.Card)
+		images, isImages := data.([]*ImageCandidate)
+		switch name := e.Name(); {
+		case name == opengraph.ExtractorName && isObject:
+			info.OpenGraph = obj
+		case name == twitter.ExtractorName && isCard:
+			info.Twitter = card
+		case name == ImagesExtractorName && isImages:
+			info.Images = images
+		default:
+			if info.Extra == nil {
+				info.Extra = make(map[string]interface{})
+			}
+			info.Extra[name] = data
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,109 @@
+package pagecard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/opengraph"
+	"github.com/mvader/pagecard/twitter"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+// headingExtractor returns the text of the first h1 of the pages of a site.
+type headingExtractor struct {
+	host string
+}
+
+func (headingExtractor) Name() string { return "heading" }
+
+func (e headingExtractor) Extract(doc *content.Document) (interface{}, error) {
+	if !strings.Contains(doc.URL, e.host) {
+		return nil, nil
+	}
+
+	var find func(*html.Node) string
+	find = func(n *html.Node) string {
+		if n.Type == html.ElementNode && n.Data == "h1" && n.FirstChild != nil {
+			return n.FirstChild.Data
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if s := find(c); s != "" {
+				return s
+			}
+		}
+		return ""
+	}
+
+	return find(doc.Root), nil
+}
+
+// copyExtractor returns a copy of the OpenGraph object of documents.
+type copyExtractor struct{}
+
+func (copyExtractor) Name() string { return "copy" }
+
+func (copyExtractor) Extract(doc *content.Document) (interface{}, error) {
+	return &opengraph.Object{Title: "Copy"}, nil
+}
+
+type failingExtractor struct{}
+
+func (failingExtractor) Name() string { return "failing" }
+
+func (failingExtractor) Extract(doc *content.Document) (interface{}, error) {
+	return nil, errors.New("failed")
+}
+
+func TestExtractors(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	assert := assert.New(t)
+	assert.Equal(Extractors(), []Extractor{opengraph.Extractor{}, twitter.Extractor{}})
+
+	Register(headingExtractor{"nope"})
+	Register(headingExtractor{"127.0.0.1"})
+	defer Unregister("heading")
+	assert.Equal(len(Extractors()), 3)
+
+	info, err := GetWithOptions(srv.URL+"/video", &Options{SkipOEmbed: true})
+	assert.Nil(err)
+	assert.Equal(info.OpenGraph.Title, "Foo")
+	assert.Equal(info.Twitter.Type, twitter.PlayerCard)
+	assert.Equal(info.Extra, map[string]interface{}{"heading": "Video"})
+
+	info, err = GetWithOptions(srv.URL+"/video", &Options{
+		SkipOEmbed: true,
+		Extractors: []string{"twitter"},
+	})
+	assert.Nil(err)
+	assert.Nil(info.OpenGraph)
+	assert.Equal(info.Twitter.Type, twitter.PlayerCard)
+	assert.Nil(info.Extra)
+
+	_, err = GetWithOptions(srv.URL+"/video", &Options{Extractors: []string{"foo"}})
+	assert.Equal(err, fmt.Errorf("unknown extractor: %s", "foo"))
+
+	Register(failingExtractor{})
+	defer Unregister("failing")
+	_, err = GetWithOptions(srv.URL+"/video", &Options{SkipOEmbed: true})
+	assert.Equal(err, errors.New("failed"))
+}
+
+func TestExtractorsRoutedByName(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	Register(copyExtractor{})
+	defer Unregister("copy")
+
+	assert := assert.New(t)
+	info, err := GetWithOptions(srv.URL+"/video", &Options{SkipOEmbed: true})
+	assert.Nil(err)
+	assert.Equal(info.OpenGraph.Title, "Foo")
+	assert.Equal(info.Extra, map[string]interface{}{"copy": &opengraph.Object{Title: "Copy"}})
+}
@@ -0,0 +1,554 @@
+package pagecard
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mvader/pagecard/content"
+	"golang.org/x/net/html"
+)
+
+// ImageSource is where an image candidate of a webpage was found.
+type ImageSource byte
+
+const (
+	// SourceOpenGraph is an og:image of the webpage.
+	SourceOpenGraph ImageSource = 1 << iota
+	// SourceTwitter is the image of the twitter card of the webpage.
+	SourceTwitter
+	// SourceJSONLD is an image of the JSON-LD structured data of the
+	// webpage.
+	SourceJSONLD
+	// SourceIcon is an icon of the webpage, declared with a link element.
+	SourceIcon
+	// SourceBody is an img element in the body of the webpage.
+	SourceBody
+)
+
+var imageSourceNames = map[ImageSource]string{
+	SourceOpenGraph: "opengraph",
+	SourceTwitter:   "twitter",
+	SourceJSONLD:    "jsonld",
+	SourceIcon:      "icon",
+	SourceBody:      "body",
+}
+
+// String returns the name of the source, or an empty string if it is not
+// a valid one.
+func (s ImageSource) String() string {
+	return imageSourceNames[s]
+}
+
+// MarshalText encodes the source as its name.
+func (s ImageSource) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a source encoded as its name.
+func (s *ImageSource) UnmarshalText(text []byte) error {
+	for source, name := range imageSourceNames {
+		if name == string(text) {
+			*s = source
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid image source: %s", text)
+}
+
+// ImageCandidate is an image that could represent a webpage.
+type ImageCandidate struct {
+	URL       string `json:"url"`
+	SecureURL string `json:"secure_url,omitempty"`
+	// Type is the MIME type of the image, if known.
+	Type string `json:"type,omitempty"`
+	Alt  string `json:"alt,omitempty"`
+	// Width and Height are the size of the image, or zero if unknown.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Probed reports whether the size of the image was read from the
+	// image itself.
+	Probed bool        `json:"probed,omitempty"`
+	Source ImageSource `json:"source"`
+}
+
+// ImagesExtractorName is the name of the ImagesExtractor.
+const ImagesExtractorName = "images"
+
+// maxBodyImages is the maximum number of img elements of the body of a
+// webpage that are considered candidates.
+const maxBodyImages = 50
+
+// ImagesExtractor finds the image candidates of documents in their JSON-LD
+// structured data, their icons and the img elements of their body, which
+// are stored in Info.Images. It is not registered by default.
+type ImagesExtractor struct{}
+
+// Name returns the name of the extractor.
+func (ImagesExtractor) Name() string {
+	return ImagesExtractorName
+}
+
+// Extract returns the image candidates of the document.
+func (ImagesExtractor) Extract(doc *content.Document) (interface{}, error) {
+	var images []*ImageCandidate
+	add := func(img *ImageCandidate) {
+		if img.URL = strings.TrimSpace(img.URL); img.URL == "" {
+			return
+		}
+
+		if img.URL = doc.ResolveURL(img.URL); img.URL != "" {
+			images = append(images, img)
+		}
+	}
+
+	for _, n := range doc.Elements("script") {
+		if strings.EqualFold(strings.TrimSpace(attr(n, "type")), "application/ld+json") && n.FirstChild != nil {
+			for _, img := range jsonLDImages(n.FirstChild.Data) {
+				add(img)
+			}
+		}
+	}
+
+	for _, l := range doc.Links {
+		if isIcon(l) {
+			img := &ImageCandidate{URL: l.Href, Type: l.Type, Source: SourceIcon}
+			img.Width, img.Height = iconSize(l.Attrs["sizes"])
+			add(img)
+		}
+	}
+
+	for i, n := range doc.Elements("img") {
+		if i == maxBodyImages {
+			break
+		}
+
+		src := attr(n, "src")
+		if src == "" || strings.HasPrefix(src, "data:") {
+			src = attr(n, "data-src")
+		}
+
+		add(&ImageCandidate{
+			URL:    src,
+			Alt:    attr(n, "alt"),
+			Width:  atoi(attr(n, "width")),
+			Height: atoi(attr(n, "height")),
+			Source: SourceBody,
+		})
+	}
+
+	if images == nil {
+		return nil, nil
+	}
+	return images, nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+var iconRels = []string{"icon", "apple-touch-icon", "apple-touch-icon-precomposed", "mask-icon"}
+
+func isIcon(l *content.Link) bool {
+	for _, rel := range iconRels {
+		if l.HasRel(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// iconSize returns the largest size of the sizes attribute of an icon,
+// such as "16x16 32x32".
+func iconSize(sizes string) (width, height int) {
+	for _, s := range strings.Fields(strings.ToLower(sizes)) {
+		i := strings.IndexByte(s, 'x')
+		if i < 0 {
+			continue
+		}
+
+		w, h := atoi(s[:i]), atoi(s[i+1:])
+		if w*h > width*height {
+			width, height = w, h
+		}
+	}
+	return width, height
+}
+
+// jsonLDImageKeys are the JSON-LD properties whose values are images
+// representing the content.
+var jsonLDImageKeys = []string{"image", "thumbnailUrl"}
+
+// jsonLDSkippedKeys are the JSON-LD properties whose values describe
+// someone else than the content, so their images are not candidates.
+var jsonLDSkippedKeys = map[string]bool{
+	"logo":      true,
+	"publisher": true,
+	"author":    true,
+	"creator":   true,
+	"brand":     true,
+}
+
+// jsonLDImages returns the images of the given JSON-LD data.
+func jsonLDImages(data string) []*ImageCandidate {
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return nil
+	}
+
+	var images []*ImageCandidate
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, e := range v {
+				walk(e)
+			}
+		case map[string]interface{}:
+			for _, k := range jsonLDImageKeys {
+				images = append(images, jsonLDImage(v[k])...)
+			}
+
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			for _, k := range keys {
+				if !jsonLDSkippedKeys[k] && k != "image" && k != "thumbnailUrl" {
+					walk(v[k])
+				}
+			}
+		}
+	}
+	walk(v)
+
+	return images
+}
+
+// jsonLDImage returns the images of the value of an image property, which
+// may be a URL, an ImageObject or a list of them.
+func jsonLDImage(v interface{}) []*ImageCandidate {
+	switch v := v.(type) {
+	case string:
+		return []*ImageCandidate{{URL: v, Source: SourceJSONLD}}
+	case []interface{}:
+		var images []*ImageCandidate
+		for _, e := range v {
+			images = append(images, jsonLDImage(e)...)
+		}
+		return images
+	case map[string]interface{}:
+		url, _ := v["url"].(string)
+		if url == "" {
+			url, _ = v["contentUrl"].(string)
+		}
+		if url == "" {
+			return nil
+		}
+
+		img := &ImageCandidate{
+			URL:    url,
+			Width:  jsonLDNumber(v["width"]),
+			Height: jsonLDNumber(v["height"]),
+			Source: SourceJSONLD,
+		}
+		img.Type, _ = v["encodingFormat"].(string)
+		img.Alt, _ = v["caption"].(string)
+		return []*ImageCandidate{img}
+	}
+	return nil
+}
+
+// jsonLDNumber returns the integer value of a number, a numeric string or
+// a QuantitativeValue.
+func jsonLDNumber(v interface{}) int {
+	switch v := v.(type) {
+	case float64:
+		if v > 0 {
+			return int(v)
+		}
+	case string:
+		return atoi(strings.TrimSuffix(strings.TrimSpace(v), "px"))
+	case map[string]interface{}:
+		return jsonLDNumber(v["value"])
+	}
+	return 0
+}
+
+const (
+	// DefaultMinImageWidth is the minimum width of the selected image if
+	// the criteria do not specify one.
+	DefaultMinImageWidth = 100
+	// DefaultMinImageHeight is the minimum height of the selected image if
+	// the criteria do not specify one.
+	DefaultMinImageHeight = 100
+	// DefaultAspectRatio is the preferred aspect ratio of the selected
+	// image if the criteria do not specify one. It is the one recommended
+	// for OpenGraph images.
+	DefaultAspectRatio = 1.91
+)
+
+// DefaultImageBlocklist contains the URL fragments of common tracking
+// pixels and placeholders.
+var DefaultImageBlocklist = []string{
+	"1x1.",
+	"pixel.gif",
+	"spacer.gif",
+	"blank.gif",
+	"transparent.gif",
+	"doubleclick.net/",
+	"google-analytics.com/",
+	"facebook.com/tr",
+}
+
+// ImageCriteria configures how the image of a webpage is selected.
+type ImageCriteria struct {
+	// MinWidth and MinHeight are the minimum size of the selected image.
+	// Images smaller than that are discarded, and images of unknown size
+	// are only discarded if they are icons. If zero, DefaultMinImageWidth
+	// and DefaultMinImageHeight are used.
+	MinWidth  int
+	MinHeight int
+	// AspectRatio is the preferred width divided by height of the image.
+	// If zero, DefaultAspectRatio is used.
+	AspectRatio float64
+	// Blocklist contains the fragments of the URLs of images that are
+	// discarded, such as tracking pixels. If nil, DefaultImageBlocklist is
+	// used.
+	Blocklist []string
+}
+
+// SelectImage returns the best image to represent the webpage according to
+// the given criteria, or nil if there is none. The candidates are the
+// OpenGraph images, the image of the twitter card and Info.Images. They
+// are ranked by where they were found, their size, how close their aspect
+// ratio is to the preferred one, whether they are available over HTTPS and
+// their type. If criteria is nil, the default criteria are used.
+func (i *Info) SelectImage(criteria *ImageCriteria) *ImageCandidate {
+	var c ImageCriteria
+	if criteria != nil {
+		c = *criteria
+	}
+
+	if c.MinWidth <= 0 {
+		c.MinWidth = DefaultMinImageWidth
+	}
+
+	if c.MinHeight <= 0 {
+		c.MinHeight = DefaultMinImageHeight
+	}
+
+	if c.AspectRatio <= 0 {
+		c.AspectRatio = DefaultAspectRatio
+	}
+
+	if c.Blocklist == nil {
+		c.Blocklist = DefaultImageBlocklist
+	}
+
+	var (
+		best      *ImageCandidate
+		bestScore float64
+	)
+	for _, img := range i.imageCandidates() {
+		if c.discards(img) {
+			continue
+		}
+
+		if score := c.score(img); best == nil || score > bestScore {
+			best, bestScore = img, score
+		}
+	}
+
+	return best
+}
+
+// imageCandidates returns the images of the webpage with their URLs
+// resolved. Images without an HTTP URL are skipped, and images with the
+// same URL are merged into the first one.
+func (i *Info) imageCandidates() []*ImageCandidate {
+	var base *url.URL
+	if i.Fetch != nil {
+		base, _ = url.Parse(i.Fetch.URL)
+	}
+
+	var candidates []*ImageCandidate
+	seen := make(map[string]*ImageCandidate)
+	add := func(img ImageCandidate) {
+		img.URL = resolveImageURL(base, img.URL)
+		img.SecureURL = resolveImageURL(base, img.SecureURL)
+		if img.URL == "" {
+			return
+		}
+
+		if prev, ok := seen[img.URL]; ok {
+			prev.merge(&img)
+			return
+		}
+
+		seen[img.URL] = &img
+		candidates = append(candidates, &img)
+	}
+
+	if og := i.OpenGraph; og != nil {
+		for _, img := range og.Images {
+			add(ImageCandidate{
+				URL:       img.URL,
+				SecureURL: img.SecureURL,
+				Type:      img.Type,
+				Width:     img.Width,
+				Height:    img.Height,
+				Probed:    img.Probed,
+				Source:    SourceOpenGraph,
+			})
+		}
+	}
+
+	if card := i.Twitter; card != nil {
+		add(ImageCandidate{
+			URL:    card.Image.URL,
+			Alt:    card.Image.Alt,
+			Width:  card.Image.Width,
+			Height: card.Image.Height,
+			Probed: card.Image.Probed,
+			Source: SourceTwitter,
+		})
+	}
+
+	for _, img := range i.Images {
+		add(*img)
+	}
+
+	return candidates
+}
+
+// merge fills the unknown values of the image with the ones of another
+// candidate with the same URL. A probed size replaces a declared one.
+func (img *ImageCandidate) merge(other *ImageCandidate) {
+	if img.SecureURL == "" {
+		img.SecureURL = other.SecureURL
+	}
+
+	if img.Type == "" {
+		img.Type = other.Type
+	}
+
+	if img.Alt == "" {
+		img.Alt = other.Alt
+	}
+
+	if (img.Width == 0 && img.Height == 0 && !img.Probed) || (other.Probed && !img.Probed) {
+		img.Width, img.Height, img.Probed = other.Width, other.Height, other.Probed
+	}
+}
+
+// discards reports whether the image is not a valid candidate.
+func (c *ImageCriteria) discards(img *ImageCandidate) bool {
+	u := strings.ToLower(img.URL)
+	for _, b := range c.Blocklist {
+		if b != "" && strings.Contains(u, strings.ToLower(b)) {
+			return true
+		}
+	}
+
+	if img.Width == 0 || img.Height == 0 {
+		return img.Source == SourceIcon
+	}
+
+	return img.Width < c.MinWidth || img.Height < c.MinHeight
+}
+
+// sourceScores are the scores of the sources of images. Images declared
+// to represent the webpage are preferred over the ones found in it.
+var sourceScores = map[ImageSource]float64{
+	SourceOpenGraph: 4,
+	SourceTwitter:   3,
+	SourceJSONLD:    2,
+	SourceBody:      1,
+	SourceIcon:      0,
+}
+
+const (
+	// largeImageArea is the area of an image from which being larger does
+	// not make it a better candidate.
+	largeImageArea = 1200 * 630
+	// maxRatioDistance is the factor between the aspect ratio of an image
+	// and the preferred one from which the image gets no score for it.
+	maxRatioDistance = 4
+	// unknownSizeScore is the score for size and aspect ratio of an image
+	// of unknown size. It may be anything, so the score is low.
+	unknownSizeScore = 1
+)
+
+// score returns how good a candidate the image is.
+func (c *ImageCriteria) score(img *ImageCandidate) float64 {
+	score := sourceScores[img.Source]
+
+	if img.Width > 0 && img.Height > 0 {
+		area := float64(img.Width * img.Height)
+		score += 3 * math.Min(area/largeImageArea, 1)
+
+		ratio := float64(img.Width) / float64(img.Height)
+		distance := math.Abs(math.Log(ratio / c.AspectRatio))
+		score += 2 * (1 - math.Min(distance, math.Log(maxRatioDistance))/math.Log(maxRatioDistance))
+	} else {
+		score += unknownSizeScore
+	}
+
+	if img.SecureURL != "" || strings.HasPrefix(strings.ToLower(img.URL), "https://") {
+		score++
+	}
+
+	switch imageType(img) {
+	case "image/jpeg", "image/png", "image/webp":
+		score++
+	case "image/svg+xml", "image/x-icon", "image/vnd.microsoft.icon":
+		score--
+	}
+
+	return score
+}
+
+var imageExtTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".webp": "image/webp",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".ico":  "image/x-icon",
+}
+
+// imageType returns the MIME type of the image, guessed from the extension
+// of its URL if unknown.
+func imageType(img *ImageCandidate) string {
+	if img.Type != "" {
+		return strings.ToLower(img.Type)
+	}
+
+	u := img.URL
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		u = u[:i]
+	}
+	return imageExtTypes[strings.ToLower(path.Ext(u))]
+}
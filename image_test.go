@@ -0,0 +1,170 @@
+package pagecard
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/opengraph"
+	"github.com/mvader/pagecard/twitter"
+	"github.com/stretchr/testify/assert"
+)
+
+const imagesDocument = `<html><head>
+<link rel="icon" href="/favicon.ico" sizes="16x16 32x32">
+<link rel="apple-touch-icon" href="/touch.png" sizes="180x180" type="image/png">
+<link rel="stylesheet" href="/style.css">
+<script type="application/ld+json">{
+	"@context": "https://schema.org",
+	"@type": "NewsArticle",
+	"image": [{"@type": "ImageObject", "url": "/article.jpg", "width": 1200, "height": "630px", "caption": "Article"}],
+	"publisher": {"@type": "Organization", "logo": {"url": "/logo.png"}},
+	"video": {"@type": "VideoObject", "thumbnailUrl": "https://cdn.foo.bar/thumb.jpg"}
+}</script>
+<script type="application/ld+json">invalid</script>
+</head><body>
+<img src="/photo.jpg" width="800" height="400" alt="Photo">
+<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-src="/lazy.png">
+<img alt="no source">
+<template><img src="/template.png"></template>
+</body></html>`
+
+func TestImagesExtractor(t *testing.T) {
+	assert := assert.New(t)
+	doc, err := content.ParseDocument(strings.NewReader(imagesDocument), content.ScopeHead)
+	assert.Nil(err)
+	doc.URL = "http://foo.bar/article"
+
+	images, err := ImagesExtractor{}.Extract(doc)
+	assert.Nil(err)
+	assert.Equal(images, []*ImageCandidate{
+		{URL: "http://foo.bar/article.jpg", Alt: "Article", Width: 1200, Height: 630, Source: SourceJSONLD},
+		{URL: "https://cdn.foo.bar/thumb.jpg", Source: SourceJSONLD},
+		{URL: "http://foo.bar/favicon.ico", Width: 32, Height: 32, Source: SourceIcon},
+		{URL: "http://foo.bar/touch.png", Type: "image/png", Width: 180, Height: 180, Source: SourceIcon},
+		{URL: "http://foo.bar/photo.jpg", Alt: "Photo", Width: 800, Height: 400, Source: SourceBody},
+		{URL: "http://foo.bar/lazy.png", Source: SourceBody},
+	})
+
+	images, err = ImagesExtractor{}.Extract(new(content.Document))
+	assert.Nil(err)
+	assert.Nil(images)
+}
+
+func TestSelectImage(t *testing.T) {
+	ogImage := func(url string, width, height int) *opengraph.Image {
+		return &opengraph.Image{
+			MediaProperties: opengraph.MediaProperties{URL: url},
+			Size:            opengraph.Size{Width: width, Height: height},
+		}
+	}
+
+	cases := []struct {
+		name     string
+		info     *Info
+		criteria *ImageCriteria
+		expected *ImageCandidate
+	}{
+		{"no images", &Info{}, nil, nil},
+		{
+			"og over twitter",
+			&Info{
+				OpenGraph: &opengraph.Object{Images: []*opengraph.Image{ogImage("/a.jpg", 1200, 630)}},
+				Twitter:   &twitter.Card{Image: twitter.Image{URL: "/b.jpg", Width: 1200, Height: 630}},
+				Fetch:     &content.FetchInfo{URL: "http://foo.bar/"},
+			},
+			nil,
+			&ImageCandidate{URL: "http://foo.bar/a.jpg", Width: 1200, Height: 630, Source: SourceOpenGraph},
+		},
+		{
+			"tiny and blocklisted images are discarded",
+			&Info{
+				OpenGraph: &opengraph.Object{Images: []*opengraph.Image{
+					ogImage("http://foo.bar/pixel.gif", 0, 0),
+					ogImage("http://foo.bar/logo.png", 50, 50),
+					ogImage("data:image/png;base64,AAAA", 1200, 630),
+				}},
+				Images: []*ImageCandidate{
+					{URL: "http://foo.bar/favicon.ico", Source: SourceIcon},
+					{URL: "http://foo.bar/photo.jpg", Width: 400, Height: 300, Source: SourceBody},
+				},
+			},
+			nil,
+			&ImageCandidate{URL: "http://foo.bar/photo.jpg", Width: 400, Height: 300, Source: SourceBody},
+		},
+		{
+			"larger image with preferred aspect ratio",
+			&Info{OpenGraph: &opengraph.Object{Images: []*opengraph.Image{
+				ogImage("http://foo.bar/square.jpg", 200, 200),
+				ogImage("http://foo.bar/banner.jpg", 2000, 200),
+				ogImage("http://foo.bar/wide.jpg", 1200, 630),
+			}}},
+			nil,
+			&ImageCandidate{URL: "http://foo.bar/wide.jpg", Width: 1200, Height: 630, Source: SourceOpenGraph},
+		},
+		{
+			"custom aspect ratio",
+			&Info{OpenGraph: &opengraph.Object{Images: []*opengraph.Image{
+				ogImage("http://foo.bar/wide.jpg", 1200, 630),
+				ogImage("http://foo.bar/square.jpg", 1000, 1000),
+			}}},
+			&ImageCriteria{AspectRatio: 1},
+			&ImageCandidate{URL: "http://foo.bar/square.jpg", Width: 1000, Height: 1000, Source: SourceOpenGraph},
+		},
+		{
+			"secure and raster images are preferred",
+			&Info{OpenGraph: &opengraph.Object{Images: []*opengraph.Image{
+				ogImage("http://foo.bar/a.svg", 0, 0),
+				ogImage("http://foo.bar/b", 0, 0),
+				{MediaProperties: opengraph.MediaProperties{URL: "http://foo.bar/c", SecureURL: "https://foo.bar/c"}},
+			}}},
+			nil,
+			&ImageCandidate{URL: "http://foo.bar/c", SecureURL: "https://foo.bar/c", Source: SourceOpenGraph},
+		},
+		{
+			"probed size of duplicates is used",
+			&Info{
+				OpenGraph: &opengraph.Object{Images: []*opengraph.Image{
+					ogImage("http://foo.bar/a.jpg", 1200, 630),
+					ogImage("http://foo.bar/b.jpg", 600, 315),
+				}},
+				Images: []*ImageCandidate{
+					{URL: "http://foo.bar/a.jpg", Width: 10, Height: 10, Probed: true, Source: SourceBody},
+				},
+			},
+			nil,
+			&ImageCandidate{URL: "http://foo.bar/b.jpg", Width: 600, Height: 315, Source: SourceOpenGraph},
+		},
+		{
+			"custom blocklist and minimum size",
+			&Info{OpenGraph: &opengraph.Object{Images: []*opengraph.Image{
+				ogImage("http://foo.bar/1x1.png", 0, 0),
+				ogImage("http://foo.bar/ads/a.jpg", 1200, 630),
+				ogImage("http://foo.bar/small.jpg", 300, 300),
+			}}},
+			&ImageCriteria{Blocklist: []string{"/ads/"}, MinWidth: 10, MinHeight: 10},
+			&ImageCandidate{URL: "http://foo.bar/small.jpg", Width: 300, Height: 300, Source: SourceOpenGraph},
+		},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		assert.Equal(c.info.SelectImage(c.criteria), c.expected, c.name)
+	}
+}
+
+func TestImageSourceText(t *testing.T) {
+	assert := assert.New(t)
+	for source, name := range imageSourceNames {
+		text, err := source.MarshalText()
+		assert.Nil(err)
+		assert.Equal(string(text), name)
+
+		var s ImageSource
+		assert.Nil(s.UnmarshalText(text))
+		assert.Equal(s, source)
+	}
+
+	var s ImageSource
+	assert.NotNil(s.UnmarshalText([]byte("foo")))
+}
@@ -0,0 +1,56 @@
+package pagecard
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is the version of the JSON encoding of Info. It changes
+// whenever a field is renamed or removed, or its meaning changes, but not
+// when fields are added.
+//
+// An Info is encoded as an object with the following fields, all of them
+// omitted if empty:
+//
+//	schema_version  the version of the schema, always present
+//	opengraph       the OpenGraph object, with snake_case names of its
+//	                properties, e.g. "site_name" and "images"
+//	twitter         the twitter card, with its type as its name in the
+//	                twitter:card metatag, and its site, creator, image,
+//	                player and app as nested objects
+//	extra           the data of other extractors, keyed by their name
+//	oembed          the oEmbed data, with the names of the specification
+//	fetch           the final URL, status, redirections and attempts
+//	images          the image candidates found by the ImagesExtractor,
+//	                with their source as its name, e.g. "jsonld"
+const SchemaVersion = 1
+
+// infoJSON is the JSON representation of an Info.
+type infoJSON struct {
+	SchemaVersion int `json:"schema_version"`
+	*infoFields
+}
+
+// infoFields has the fields of Info without its methods, so encoding it
+// does not recurse.
+type infoFields Info
+
+// MarshalJSON encodes the Info as JSON with the current SchemaVersion.
+func (i Info) MarshalJSON() ([]byte, error) {
+	return json.Marshal(infoJSON{SchemaVersion, (*infoFields)(&i)})
+}
+
+// UnmarshalJSON decodes an Info encoded as JSON. Encodings with a newer
+// schema version are rejected, and encodings without a version are
+// considered to have the current one.
+func (i *Info) UnmarshalJSON(data []byte) error {
+	j := infoJSON{infoFields: (*infoFields)(i)}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	if j.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("unsupported schema version: %d", j.SchemaVersion)
+	}
+	return nil
+}
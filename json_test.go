@@ -0,0 +1,122 @@
+package pagecard
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/oembed"
+	"github.com/mvader/pagecard/opengraph"
+	"github.com/mvader/pagecard/twitter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInfoJSON(t *testing.T) {
+	info := &Info{
+		OpenGraph: &opengraph.Object{
+			Title:    "Foo",
+			Type:     "video.other",
+			SiteName: "Bar",
+			Images: []*opengraph.Image{{
+				MediaProperties: opengraph.MediaProperties{URL: "http://foo.bar/a.png"},
+				Size:            opengraph.Size{Width: 200, Height: 100},
+			}},
+		},
+		Twitter: &twitter.Card{
+			Type:    twitter.PlayerCard,
+			Site:    twitter.Site{User: "@site"},
+			Creator: twitter.Creator{ID: "1234"},
+			Labels:  []*twitter.Label{{Label: "Length", Data: "3 min"}},
+			Player:  &twitter.Player{URL: "https://foo.bar/player", Width: 640},
+			App: &twitter.App{
+				IPhone: twitter.AppInfo{ID: "1"},
+				Others: map[string]*twitter.AppInfo{"windows": {Name: "Foo"}},
+			},
+		},
+		Extra: map[string]interface{}{
+			"price": map[string]interface{}{"amount": 10.5, "currency": "EUR"},
+		},
+		OEmbed: &oembed.Response{Type: "video", HTML: "<iframe></iframe>", Width: 640},
+		Fetch: &content.FetchInfo{
+			URL:    "http://foo.bar/final",
+			Status: 200,
+			Hops: []*content.Hop{
+				{URL: "http://foo.bar", Status: 301, Location: "/final", Kind: content.HopHTTP},
+			},
+			Attempts: 1,
+		},
+	}
+
+	assert := assert.New(t)
+	data, err := json.Marshal(info)
+	assert.Nil(err)
+	assert.JSONEq(string(data), `{
+		"schema_version": 1,
+		"opengraph": {
+			"title": "Foo",
+			"type": "video.other",
+			"site_name": "Bar",
+			"images": [{"url": "http://foo.bar/a.png", "width": 200, "height": 100}]
+		},
+		"twitter": {
+			"type": "player",
+			"labels": [{"label": "Length", "data": "3 min"}],
+			"site": {"user": "@site"},
+			"creator": {"id": "1234"},
+			"player": {"url": "https://foo.bar/player", "width": 640},
+			"app": {"iphone": {"id": "1"}, "others": {"windows": {"name": "Foo"}}}
+		},
+		"extra": {"price": {"amount": 10.5, "currency": "EUR"}},
+		"oembed": {"type": "video", "html": "<iframe></iframe>", "width": 640},
+		"fetch": {
+			"url": "http://foo.bar/final",
+			"status": 200,
+			"hops": [{"url": "http://foo.bar", "status": 301, "location": "/final", "kind": "http"}],
+			"attempts": 1
+		}
+	}`)
+
+	var decoded Info
+	assert.Nil(json.Unmarshal(data, &decoded))
+	assert.Equal(&decoded, info)
+
+	data, err = json.Marshal(new(Info))
+	assert.Nil(err)
+	assert.Equal(string(data), `{"schema_version":1}`)
+
+	data, err = json.Marshal(Info{})
+	assert.Nil(err)
+	assert.Equal(string(data), `{"schema_version":1}`)
+}
+
+func TestInfoJSONVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	var info Info
+	assert.Nil(json.Unmarshal([]byte(`{"opengraph": {"title": "Foo"}}`), &info))
+	assert.Equal(info.OpenGraph.Title, "Foo")
+
+	err := json.Unmarshal([]byte(`{"schema_version": 2}`), &info)
+	assert.Equal(err.Error(), "unsupported schema version: 2")
+}
+
+func TestPreviewJSON(t *testing.T) {
+	p := &Preview{
+		Type:  twitter.SummaryBigPictureCard,
+		Title: "Foo",
+		Image: &PreviewImage{URL: "http://foo.bar/a.png", Width: 300},
+	}
+
+	assert := assert.New(t)
+	data, err := json.Marshal(p)
+	assert.Nil(err)
+	assert.JSONEq(string(data), `{
+		"type": "summary_large_image",
+		"title": "Foo",
+		"image": {"url": "http://foo.bar/a.png", "width": 300}
+	}`)
+
+	var decoded Preview
+	assert.Nil(json.Unmarshal(data, &decoded))
+	assert.Equal(&decoded, p)
+}
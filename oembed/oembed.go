@@ -0,0 +1,300 @@
+package oembed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mvader/pagecard/content"
+)
+
+// ErrTooLarge is returned when a response exceeds the maximum size in
+// bytes.
+var ErrTooLarge = errors.New("oembed: response too large")
+
+// DefaultMaxBytes is the maximum size in bytes of a response if the options
+// do not specify one.
+const DefaultMaxBytes = 1 << 20
+
+// Response is the oEmbed representation of a webpage. It is encoded as
+// JSON with the names of the oEmbed specification.
+type Response struct {
+	Type            string `json:"type,omitempty"`
+	Version         string `json:"version,omitempty"`
+	Title           string `json:"title,omitempty"`
+	AuthorName      string `json:"author_name,omitempty"`
+	AuthorURL       string `json:"author_url,omitempty"`
+	ProviderName    string `json:"provider_name,omitempty"`
+	ProviderURL     string `json:"provider_url,omitempty"`
+	CacheAge        int    `json:"cache_age,omitempty"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty"`
+	// URL is the source URL of the image of "photo" responses.
+	URL string `json:"url,omitempty"`
+	// HTML is the markup to embed "video" and "rich" responses.
+	HTML   string `json:"html,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// Format is the format of an oEmbed response.
+type Format byte
+
+const (
+	// JSON is the format of responses encoded as JSON.
+	JSON Format = 1 << iota
+	// XML is the format of responses encoded as XML.
+	XML
+)
+
+// Options configures the oEmbed requests.
+type Options struct {
+	// MaxWidth is the maximum width of the embedded resource. If zero, no
+	// maximum is requested.
+	MaxWidth int
+	// MaxHeight is the maximum height of the embedded resource. If zero,
+	// no maximum is requested.
+	MaxHeight int
+	// MaxBytes is the maximum size in bytes of the response, which is
+	// read from an endpoint advertised by the page. If zero,
+	// DefaultMaxBytes is used.
+	MaxBytes int64
+}
+
+const (
+	alternateRel = "alternate"
+	jsonType     = "application/json+oembed"
+	xmlType      = "text/xml+oembed"
+	altXMLType   = "application/xml+oembed"
+)
+
+// Discover returns the URL of the oEmbed endpoint advertised by the
+// document with a link element, and the format of its responses. JSON
+// endpoints are preferred over XML ones.
+func Discover(doc *content.Document) (string, Format, bool) {
+	var (
+		endpoint string
+		format   Format
+	)
+
+	for _, l := range doc.Links {
+		if !l.HasRel(alternateRel) {
+			continue
+		}
+
+		var f Format
+		switch strings.ToLower(strings.TrimSpace(l.Type)) {
+		case jsonType:
+			f = JSON
+		case xmlType, altXMLType:
+			f = XML
+		default:
+			continue
+		}
+
+		href := doc.ResolveURL(l.Href)
+		if href == "" {
+			continue
+		}
+
+		if f == JSON {
+			return href, JSON, true
+		}
+
+		if endpoint == "" {
+			endpoint, format = href, f
+		}
+	}
+
+	return endpoint, format, endpoint != ""
+}
+
+// Fetch requests the oEmbed response at the given endpoint, which must
+// already contain the URL of the resource, using the given client. If the
+// client is nil, http.DefaultClient is used.
+func Fetch(client *http.Client, endpoint string, format Format, opts *Options) (*Response, error) {
+	return FetchContext(context.Background(), client, endpoint, format, opts)
+}
+
+// FetchContext is like Fetch, but the request is canceled if the given
+// context is done before it finishes.
+func FetchContext(
+	ctx context.Context,
+	client *http.Client,
+	endpoint string,
+	format Format,
+	opts *Options,
+) (*Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts != nil {
+		q := u.Query()
+		if opts.MaxWidth > 0 {
+			q.Set("maxwidth", strconv.Itoa(opts.MaxWidth))
+		}
+		if opts.MaxHeight > 0 {
+			q.Set("maxheight", strconv.Itoa(opts.MaxHeight))
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oembed: unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := readAll(resp.Body, maxBytes(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	var r Response
+	if format == XML {
+		err = xml.NewDecoder(bytes.NewReader(data)).Decode(&r)
+	} else {
+		err = json.NewDecoder(bytes.NewReader(data)).Decode(&r)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func maxBytes(opts *Options) int64 {
+	if opts == nil || opts.MaxBytes <= 0 {
+		return DefaultMaxBytes
+	}
+	return opts.MaxBytes
+}
+
+// readAll reads r until EOF, failing with ErrTooLarge if it is longer than
+// max bytes.
+func readAll(r io.Reader, max int64) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(io.LimitReader(r, max+1)); err != nil {
+		return nil, err
+	}
+
+	if int64(buf.Len()) > max {
+		return nil, ErrTooLarge
+	}
+	return buf.Bytes(), nil
+}
+
+// wireResponse is the representation of a response in the wire. Numbers
+// are decoded leniently, since many providers send them as strings.
+type wireResponse struct {
+	XMLName         xml.Name `json:"-" xml:"oembed"`
+	Type            string   `json:"type" xml:"type"`
+	Version         number   `json:"version" xml:"version"`
+	Title           string   `json:"title" xml:"title"`
+	AuthorName      string   `json:"author_name" xml:"author_name"`
+	AuthorURL       string   `json:"author_url" xml:"author_url"`
+	ProviderName    string   `json:"provider_name" xml:"provider_name"`
+	ProviderURL     string   `json:"provider_url" xml:"provider_url"`
+	CacheAge        number   `json:"cache_age" xml:"cache_age"`
+	ThumbnailURL    string   `json:"thumbnail_url" xml:"thumbnail_url"`
+	ThumbnailWidth  number   `json:"thumbnail_width" xml:"thumbnail_width"`
+	ThumbnailHeight number   `json:"thumbnail_height" xml:"thumbnail_height"`
+	URL             string   `json:"url" xml:"url"`
+	HTML            string   `json:"html" xml:"html"`
+	Width           number   `json:"width" xml:"width"`
+	Height          number   `json:"height" xml:"height"`
+}
+
+func (w *wireResponse) response() Response {
+	return Response{
+		Type:            w.Type,
+		Version:         string(w.Version),
+		Title:           w.Title,
+		AuthorName:      w.AuthorName,
+		AuthorURL:       w.AuthorURL,
+		ProviderName:    w.ProviderName,
+		ProviderURL:     w.ProviderURL,
+		CacheAge:        w.CacheAge.int(),
+		ThumbnailURL:    w.ThumbnailURL,
+		ThumbnailWidth:  w.ThumbnailWidth.int(),
+		ThumbnailHeight: w.ThumbnailHeight.int(),
+		URL:             w.URL,
+		HTML:            w.HTML,
+		Width:           w.Width.int(),
+		Height:          w.Height.int(),
+	}
+}
+
+// UnmarshalJSON decodes an oEmbed response encoded as JSON.
+func (r *Response) UnmarshalJSON(data []byte) error {
+	var w wireResponse
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*r = w.response()
+	return nil
+}
+
+// UnmarshalXML decodes an oEmbed response encoded as XML.
+func (r *Response) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var w wireResponse
+	if err := d.DecodeElement(&w, &start); err != nil {
+		return err
+	}
+
+	*r = w.response()
+	return nil
+}
+
+// number is a numeric value that may be encoded as a JSON number or string.
+type number string
+
+func (n *number) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*n = number(s)
+		return nil
+	}
+
+	if string(data) != "null" {
+		*n = number(data)
+	}
+	return nil
+}
+
+func (n number) int() int {
+	f, err := strconv.ParseFloat(strings.TrimSpace(string(n)), 64)
+	if err != nil {
+		return 0
+	}
+	return int(f)
+}
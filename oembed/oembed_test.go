@@ -0,0 +1,166 @@
+package oembed
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscover(t *testing.T) {
+	cases := []struct {
+		html     string
+		endpoint string
+		format   Format
+		ok       bool
+	}{
+		{`<link rel="stylesheet" href="/style.css">`, "", 0, false},
+		{
+			`<link rel="alternate" type="application/json+oembed" href="/oembed?url=foo&format=json">`,
+			"http://foo.bar/oembed?url=foo&format=json", JSON, true,
+		},
+		{
+			`<link rel="alternate" type="text/xml+oembed" href="http://baz/oembed.xml">
+			 <link rel="alternate" type="application/json+oembed" href="http://baz/oembed.json">`,
+			"http://baz/oembed.json", JSON, true,
+		},
+		{
+			`<link rel="Alternate" type="application/xml+oembed" href="http://baz/oembed.xml">`,
+			"http://baz/oembed.xml", XML, true,
+		},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		doc, err := content.ParseDocument(strings.NewReader("<head>"+c.html+"</head>"), content.ScopeHead)
+		assert.Nil(err)
+		doc.URL = "http://foo.bar/baz"
+
+		endpoint, format, ok := Discover(doc)
+		assert.Equal(endpoint, c.endpoint)
+		assert.Equal(format, c.format)
+		assert.Equal(ok, c.ok)
+	}
+}
+
+const (
+	jsonResponse = `{
+	"type": "video",
+	"version": "1.0",
+	"title": "Foo",
+	"author_name": "Bar",
+	"author_url": "http://bar",
+	"provider_name": "Baz",
+	"provider_url": "http://baz",
+	"thumbnail_url": "http://baz/thumb.jpg",
+	"thumbnail_width": 480,
+	"thumbnail_height": "360",
+	"html": "<iframe src=\"http://baz/embed\"></iframe>",
+	"width": %s,
+	"height": 270
+}`
+	xmlResponse = `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<oembed>
+	<type>photo</type>
+	<version>1.0</version>
+	<title>Foo</title>
+	<cache_age>3600</cache_age>
+	<url>http://baz/photo.jpg</url>
+	<width>%s</width>
+	<height>300</height>
+</oembed>`
+)
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		width := r.URL.Query().Get("maxwidth")
+		if width == "" {
+			width = "640"
+		}
+
+		switch r.URL.Query().Get("format") {
+		case "json":
+			fmt.Fprintf(w, jsonResponse, width)
+		case "xml":
+			fmt.Fprintf(w, xmlResponse, width)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	assert := assert.New(t)
+	resp, err := Fetch(nil, srv.URL+"/oembed?format=json", JSON, nil)
+	assert.Nil(err)
+	assert.Equal(resp, &Response{
+		Type:            "video",
+		Version:         "1.0",
+		Title:           "Foo",
+		AuthorName:      "Bar",
+		AuthorURL:       "http://bar",
+		ProviderName:    "Baz",
+		ProviderURL:     "http://baz",
+		ThumbnailURL:    "http://baz/thumb.jpg",
+		ThumbnailWidth:  480,
+		ThumbnailHeight: 360,
+		HTML:            `<iframe src="http://baz/embed"></iframe>`,
+		Width:           640,
+		Height:          270,
+	})
+
+	resp, err = Fetch(nil, srv.URL+"/oembed?format=json", JSON, &Options{MaxWidth: 320})
+	assert.Nil(err)
+	assert.Equal(resp.Width, 320)
+
+	resp, err = Fetch(nil, srv.URL+"/oembed?format=xml", XML, &Options{MaxWidth: 400, MaxHeight: 300})
+	assert.Nil(err)
+	assert.Equal(resp, &Response{
+		Type:     "photo",
+		Version:  "1.0",
+		Title:    "Foo",
+		CacheAge: 3600,
+		URL:      "http://baz/photo.jpg",
+		Width:    400,
+		Height:   300,
+	})
+
+	_, err = Fetch(nil, srv.URL+"/oembed", JSON, nil)
+	assert.Equal(err, fmt.Errorf("oembed: unexpected status code: %d", 404))
+
+	_, err = Fetch(nil, srv.URL+"/oembed?format=json", JSON, &Options{MaxBytes: 100})
+	assert.Equal(err, ErrTooLarge)
+}
+
+func TestResponseJSON(t *testing.T) {
+	resp := &Response{
+		Type:           "video",
+		Version:        "1.0",
+		AuthorName:     "Foo",
+		ThumbnailURL:   "http://foo.bar/thumb.jpg",
+		ThumbnailWidth: 480,
+		HTML:           "<iframe></iframe>",
+		Width:          640,
+	}
+
+	assert := assert.New(t)
+	data, err := json.Marshal(resp)
+	assert.Nil(err)
+	assert.JSONEq(string(data), `{
+		"type": "video",
+		"version": "1.0",
+		"author_name": "Foo",
+		"thumbnail_url": "http://foo.bar/thumb.jpg",
+		"thumbnail_width": 480,
+		"html": "<iframe></iframe>",
+		"width": 640
+	}`)
+
+	var decoded Response
+	assert.Nil(json.Unmarshal(data, &decoded))
+	assert.Equal(&decoded, resp)
+}
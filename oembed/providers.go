@@ -0,0 +1,195 @@
+package oembed
+
+// providersJSON contains the built-in providers, in the format of
+// https://oembed.com/providers.json.
+const providersJSON = `[
+  {
+    "provider_name": "CodePen",
+    "provider_url": "https://codepen.io",
+    "endpoints": [
+      {
+        "schemes": ["http://codepen.io/*", "https://codepen.io/*"],
+        "url": "https://codepen.io/api/oembed"
+      }
+    ]
+  },
+  {
+    "provider_name": "Dailymotion",
+    "provider_url": "https://www.dailymotion.com",
+    "endpoints": [
+      {
+        "schemes": ["https://www.dailymotion.com/video/*", "https://dai.ly/*"],
+        "url": "https://www.dailymotion.com/services/oembed",
+        "discovery": true
+      }
+    ]
+  },
+  {
+    "provider_name": "Flickr",
+    "provider_url": "https://www.flickr.com/",
+    "endpoints": [
+      {
+        "schemes": [
+          "http://*.flickr.com/photos/*",
+          "http://flic.kr/p/*",
+          "https://*.flickr.com/photos/*",
+          "https://flic.kr/p/*"
+        ],
+        "url": "https://www.flickr.com/services/oembed/",
+        "discovery": true
+      }
+    ]
+  },
+  {
+    "provider_name": "GIPHY",
+    "provider_url": "https://giphy.com",
+    "endpoints": [
+      {
+        "schemes": [
+          "https://giphy.com/gifs/*",
+          "https://gph.is/*",
+          "https://media.giphy.com/media/*/giphy.gif"
+        ],
+        "url": "https://giphy.com/services/oembed",
+        "discovery": true
+      }
+    ]
+  },
+  {
+    "provider_name": "Kickstarter",
+    "provider_url": "https://www.kickstarter.com",
+    "endpoints": [
+      {
+        "schemes": ["https://www.kickstarter.com/projects/*"],
+        "url": "https://www.kickstarter.com/services/oembed"
+      }
+    ]
+  },
+  {
+    "provider_name": "Reddit",
+    "provider_url": "https://reddit.com/",
+    "endpoints": [
+      {
+        "schemes": [
+          "https://reddit.com/r/*/comments/*/*",
+          "https://www.reddit.com/r/*/comments/*/*"
+        ],
+        "url": "https://www.reddit.com/oembed"
+      }
+    ]
+  },
+  {
+    "provider_name": "SlideShare",
+    "provider_url": "https://www.slideshare.net/",
+    "endpoints": [
+      {
+        "schemes": [
+          "https://www.slideshare.net/*/*",
+          "http://www.slideshare.net/*/*",
+          "https://slideshare.net/*/*"
+        ],
+        "url": "https://www.slideshare.net/api/oembed/2",
+        "discovery": true
+      }
+    ]
+  },
+  {
+    "provider_name": "SoundCloud",
+    "provider_url": "https://soundcloud.com/",
+    "endpoints": [
+      {
+        "schemes": [
+          "http://soundcloud.com/*",
+          "https://soundcloud.com/*",
+          "https://on.soundcloud.com/*"
+        ],
+        "url": "https://soundcloud.com/oembed"
+      }
+    ]
+  },
+  {
+    "provider_name": "Speaker Deck",
+    "provider_url": "https://speakerdeck.com",
+    "endpoints": [
+      {
+        "schemes": ["https://speakerdeck.com/*/*", "https://speakerdeck.com/player/*"],
+        "url": "https://speakerdeck.com/oembed.{format}",
+        "discovery": true,
+        "formats": ["json"]
+      }
+    ]
+  },
+  {
+    "provider_name": "Spotify",
+    "provider_url": "https://spotify.com/",
+    "endpoints": [
+      {
+        "schemes": ["https://open.spotify.com/*", "spotify:*"],
+        "url": "https://open.spotify.com/oembed/",
+        "discovery": true
+      }
+    ]
+  },
+  {
+    "provider_name": "TikTok",
+    "provider_url": "http://www.tiktok.com/",
+    "endpoints": [
+      {
+        "schemes": ["https://www.tiktok.com/*", "https://www.tiktok.com/*/video/*"],
+        "url": "https://www.tiktok.com/oembed"
+      }
+    ]
+  },
+  {
+    "provider_name": "Twitter",
+    "provider_url": "http://www.twitter.com/",
+    "endpoints": [
+      {
+        "schemes": [
+          "https://twitter.com/*",
+          "https://twitter.com/*/status/*",
+          "https://*.twitter.com/*/status/*",
+          "https://x.com/*/status/*"
+        ],
+        "url": "https://publish.twitter.com/oembed"
+      }
+    ]
+  },
+  {
+    "provider_name": "Vimeo",
+    "provider_url": "https://vimeo.com/",
+    "endpoints": [
+      {
+        "schemes": [
+          "https://vimeo.com/*",
+          "https://vimeo.com/album/*/video/*",
+          "https://vimeo.com/channels/*/*",
+          "https://vimeo.com/groups/*/videos/*",
+          "https://vimeo.com/ondemand/*/*",
+          "https://player.vimeo.com/video/*"
+        ],
+        "url": "https://vimeo.com/api/oembed.{format}",
+        "discovery": true
+      }
+    ]
+  },
+  {
+    "provider_name": "YouTube",
+    "provider_url": "https://www.youtube.com/",
+    "endpoints": [
+      {
+        "schemes": [
+          "https://*.youtube.com/watch*",
+          "https://*.youtube.com/v/*",
+          "https://youtu.be/*",
+          "https://*.youtube.com/playlist?list=*",
+          "https://youtube.com/playlist?list=*",
+          "https://*.youtube.com/shorts*"
+        ],
+        "url": "https://www.youtube.com/oembed",
+        "discovery": true
+      }
+    ]
+  }
+]
+`
@@ -0,0 +1,155 @@
+package oembed
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Registry is a list of oEmbed providers, used to find the oEmbed endpoint
+// of URLs whose pages do not advertise it.
+type Registry struct {
+	Providers []*Provider
+}
+
+// Provider is a site that exposes its resources with oEmbed.
+type Provider struct {
+	Name      string      `json:"provider_name"`
+	URL       string      `json:"provider_url"`
+	Endpoints []*Endpoint `json:"endpoints"`
+}
+
+// Endpoint is an oEmbed endpoint of a provider and the URL schemes of the
+// resources it can represent.
+type Endpoint struct {
+	// Schemes are the URL patterns matched by the endpoint, where "*"
+	// matches any sequence of characters.
+	Schemes []string `json:"schemes"`
+	// URL is the URL of the endpoint. It may contain a "{format}"
+	// placeholder that is replaced by the format requested.
+	URL       string   `json:"url"`
+	Discovery bool     `json:"discovery"`
+	Formats   []string `json:"formats"`
+
+	patterns []*regexp.Regexp
+}
+
+const formatPlaceholder = "{format}"
+
+// DefaultRegistry contains a built-in selection of the providers listed in
+// https://oembed.com/providers.json.
+var DefaultRegistry = mustRegistry(strings.NewReader(providersJSON))
+
+// NewRegistry reads a registry in the format of the providers.json file of
+// https://oembed.com.
+func NewRegistry(r io.Reader) (*Registry, error) {
+	var providers []*Provider
+	if err := json.NewDecoder(r).Decode(&providers); err != nil {
+		return nil, err
+	}
+
+	for _, p := range providers {
+		for _, e := range p.Endpoints {
+			for _, s := range e.Schemes {
+				e.patterns = append(e.patterns, schemePattern(s))
+			}
+		}
+	}
+
+	return &Registry{providers}, nil
+}
+
+// LoadRegistry reads a registry from the file at the given path, in the
+// format of the providers.json file of https://oembed.com.
+func LoadRegistry(path string) (*Registry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return NewRegistry(f)
+}
+
+func mustRegistry(r io.Reader) *Registry {
+	reg, err := NewRegistry(r)
+	if err != nil {
+		panic(err)
+	}
+	return reg
+}
+
+// Match returns the provider and endpoint whose schemes match the given
+// URL.
+func (r *Registry) Match(u string) (*Provider, *Endpoint, bool) {
+	for _, p := range r.Providers {
+		for _, e := range p.Endpoints {
+			for _, pattern := range e.patterns {
+				if pattern.MatchString(u) {
+					return p, e, true
+				}
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// Endpoint returns the URL of the oEmbed request for the resource at the
+// given URL and the format of its response, if any provider of the
+// registry matches it.
+func (r *Registry) Endpoint(u string) (string, Format, bool) {
+	_, e, ok := r.Match(u)
+	if !ok {
+		return "", 0, false
+	}
+
+	format, name := JSON, "json"
+	if !e.supports(name) {
+		format, name = XML, "xml"
+	}
+
+	endpoint := e.URL
+	if strings.Contains(endpoint, formatPlaceholder) {
+		endpoint = strings.Replace(endpoint, formatPlaceholder, name, -1)
+	}
+
+	eu, err := url.Parse(endpoint)
+	if err != nil {
+		return "", 0, false
+	}
+
+	q := eu.Query()
+	q.Set("url", u)
+	if !strings.Contains(e.URL, formatPlaceholder) {
+		q.Set("format", name)
+	}
+	eu.RawQuery = q.Encode()
+
+	return eu.String(), format, true
+}
+
+// supports reports whether the endpoint supports the given format. All
+// endpoints are assumed to support JSON if they do not list their formats.
+func (e *Endpoint) supports(format string) bool {
+	if len(e.Formats) == 0 {
+		return format == "json"
+	}
+
+	for _, f := range e.Formats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+func schemePattern(scheme string) *regexp.Regexp {
+	parts := strings.Split(scheme, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
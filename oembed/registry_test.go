@@ -0,0 +1,92 @@
+package oembed
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const registryFixture = `[
+  {
+    "provider_name": "Foo",
+    "provider_url": "http://foo.bar",
+    "endpoints": [
+      {
+        "schemes": ["http://foo.bar/videos/*", "http://*.foo.bar/v/*"],
+        "url": "http://foo.bar/oembed.{format}"
+      }
+    ]
+  },
+  {
+    "provider_name": "Baz",
+    "provider_url": "http://baz.qux",
+    "endpoints": [
+      {
+        "schemes": ["http://baz.qux/*/photo/*"],
+        "url": "http://baz.qux/oembed",
+        "formats": ["xml"]
+      }
+    ]
+  }
+]`
+
+func TestRegistryEndpoint(t *testing.T) {
+	assert := assert.New(t)
+	reg, err := NewRegistry(strings.NewReader(registryFixture))
+	assert.Nil(err)
+
+	cases := []struct {
+		url      string
+		endpoint string
+		format   Format
+		ok       bool
+	}{
+		{"http://foo.bar/videos/1", "http://foo.bar/oembed.json?url=http%3A%2F%2Ffoo.bar%2Fvideos%2F1", JSON, true},
+		{"http://www.foo.bar/v/1", "http://foo.bar/oembed.json?url=http%3A%2F%2Fwww.foo.bar%2Fv%2F1", JSON, true},
+		{"http://baz.qux/me/photo/1", "http://baz.qux/oembed?format=xml&url=http%3A%2F%2Fbaz.qux%2Fme%2Fphoto%2F1", XML, true},
+		{"http://foo.bar/photos/1", "", 0, false},
+		{"https://foo.bar/videos/1", "", 0, false},
+		{"http://baz.qux/photo/1", "", 0, false},
+	}
+
+	for _, c := range cases {
+		endpoint, format, ok := reg.Endpoint(c.url)
+		assert.Equal(endpoint, c.endpoint, c.url)
+		assert.Equal(format, c.format, c.url)
+		assert.Equal(ok, c.ok, c.url)
+	}
+}
+
+func TestLoadRegistry(t *testing.T) {
+	assert := assert.New(t)
+	f, err := ioutil.TempFile("", "pagecard")
+	assert.Nil(err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(registryFixture)
+	assert.Nil(err)
+	assert.Nil(f.Close())
+
+	reg, err := LoadRegistry(f.Name())
+	assert.Nil(err)
+	assert.Equal(len(reg.Providers), 2)
+	assert.Equal(reg.Providers[1].Name, "Baz")
+
+	_, err = NewRegistry(strings.NewReader("{"))
+	assert.NotNil(err)
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	assert := assert.New(t)
+	p, _, ok := DefaultRegistry.Match("https://www.youtube.com/watch?v=dQw4w9WgXcQ")
+	assert.True(ok)
+	assert.Equal(p.Name, "YouTube")
+
+	endpoint, format, ok := DefaultRegistry.Endpoint("https://vimeo.com/76979871")
+	assert.True(ok)
+	assert.Equal(format, JSON)
+	assert.Equal(endpoint, "https://vimeo.com/api/oembed.json?url=https%3A%2F%2Fvimeo.com%2F76979871")
+}
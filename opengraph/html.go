@@ -0,0 +1,96 @@
+package opengraph
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	errImageWithoutURL = errors.New("invalid image: og:image requires a URL")
+	errVideoWithoutURL = errors.New("invalid video: og:video requires a URL")
+	errAudioWithoutURL = errors.New("invalid audio: og:audio requires a URL")
+)
+
+// MarshalHTML encodes the object as the <meta> tags that declare it, one
+// per line, in the order they are expected by NewObject. Empty values are
+// omitted, and media without URL cannot be encoded, since their
+// properties need to follow it. NUL characters cannot be represented in
+// HTML, and are read back as U+FFFD.
+func (o *Object) MarshalHTML() ([]byte, error) {
+	var w metaWriter
+	w.write(title, o.Title)
+	w.write(typ, o.Type)
+	w.write(url, o.URL)
+	w.write(description, o.Description)
+	for _, d := range o.Determiners {
+		w.write(determiner, d)
+	}
+	w.write(locale, o.Locale)
+	for _, l := range o.AlternateLocales {
+		w.write(altLocale, l)
+	}
+	w.write(siteName, o.SiteName)
+
+	for _, img := range o.Images {
+		if img.URL == "" {
+			return nil, errImageWithoutURL
+		}
+		w.writeMedia(image, img.MediaProperties)
+		w.writeSize(image, img.Size)
+	}
+
+	for _, vid := range o.Videos {
+		if vid.URL == "" {
+			return nil, errVideoWithoutURL
+		}
+		w.writeMedia(video, vid.MediaProperties)
+		w.writeSize(video, vid.Size)
+	}
+
+	for _, aud := range o.Audios {
+		if aud.URL == "" {
+			return nil, errAudioWithoutURL
+		}
+		w.writeMedia(audio, aud.MediaProperties)
+	}
+
+	return w.Bytes(), nil
+}
+
+// metaWriter writes OpenGraph <meta> tags.
+type metaWriter struct {
+	bytes.Buffer
+}
+
+// write writes the <meta> tag of the given property without the og:
+// prefix, unless the value is empty.
+func (w *metaWriter) write(name, value string) {
+	if value == "" {
+		return
+	}
+
+	w.WriteString(`<meta property="`)
+	w.WriteString(html.EscapeString(ogPrefix + name))
+	w.WriteString(`" content="`)
+	w.WriteString(html.EscapeString(value))
+	w.WriteString("\">\n")
+}
+
+func (w *metaWriter) writeMedia(root string, m MediaProperties) {
+	w.write(root, m.URL)
+	w.write(root+":"+secURL, m.SecureURL)
+	w.write(root+":"+typ, m.Type)
+}
+
+func (w *metaWriter) writeSize(root string, s Size) {
+	if s.Width != 0 {
+		w.write(root+":"+width, strconv.Itoa(s.Width))
+	}
+
+	if s.Height != 0 {
+		w.write(root+":"+height, strconv.Itoa(s.Height))
+	}
+}
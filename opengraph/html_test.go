@@ -0,0 +1,139 @@
+package opengraph
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalHTML(t *testing.T) {
+	obj := &Object{
+		Title:       `Tom & Jerry's "<show>"`,
+		Type:        "video.tv_show",
+		Determiners: []string{"the"},
+		Images: []*Image{{
+			MediaProperties: MediaProperties{URL: "http://foo.bar/a.png", Type: "image/png"},
+			Size:            Size{Width: 200},
+		}},
+		Audios: []*Audio{{MediaProperties{URL: "http://foo.bar/a.mp3"}}},
+	}
+
+	assert := assert.New(t)
+	data, err := obj.MarshalHTML()
+	assert.Nil(err)
+	assert.Equal(string(data), `<meta property="og:title" content="Tom &amp; Jerry&#39;s &#34;&lt;show&gt;&#34;">
+<meta property="og:type" content="video.tv_show">
+<meta property="og:determiner" content="the">
+<meta property="og:image" content="http://foo.bar/a.png">
+<meta property="og:image:type" content="image/png">
+<meta property="og:image:width" content="200">
+<meta property="og:audio" content="http://foo.bar/a.mp3">
+`)
+
+	for _, o := range []*Object{
+		{Images: []*Image{{Size: Size{Width: 200}}}},
+		{Videos: []*Video{{MediaProperties: MediaProperties{Type: "video/mp4"}}}},
+		{Audios: []*Audio{{}}},
+	} {
+		_, err := o.MarshalHTML()
+		assert.NotNil(err)
+	}
+}
+
+// randomObject is an Object with random values that can be encoded as
+// HTML.
+type randomObject struct {
+	*Object
+}
+
+func (randomObject) Generate(r *rand.Rand, size int) reflect.Value {
+	obj := &Object{
+		Title:            randomString(r),
+		Type:             randomString(r),
+		URL:              randomString(r),
+		Description:      randomString(r),
+		Locale:           randomString(r),
+		AlternateLocales: randomStrings(r),
+		Determiners:      randomStrings(r),
+		SiteName:         randomString(r),
+	}
+
+	for i := r.Intn(3); i > 0; i-- {
+		obj.Images = append(obj.Images, &Image{MediaProperties: randomMedia(r), Size: randomSize(r)})
+	}
+
+	for i := r.Intn(3); i > 0; i-- {
+		obj.Videos = append(obj.Videos, &Video{randomMedia(r), randomSize(r)})
+	}
+
+	for i := r.Intn(3); i > 0; i-- {
+		obj.Audios = append(obj.Audios, &Audio{randomMedia(r)})
+	}
+
+	return reflect.ValueOf(randomObject{obj})
+}
+
+func TestMarshalHTMLRoundTrip(t *testing.T) {
+	roundTrip := func(o randomObject) bool {
+		data, err := o.MarshalHTML()
+		if err != nil {
+			return false
+		}
+
+		meta, err := content.Parse(bytes.NewReader(data), content.ScopeHead)
+		if err != nil {
+			return false
+		}
+
+		obj, err := NewObject(meta)
+		return err == nil && reflect.DeepEqual(obj, o.Object)
+	}
+
+	assert.Nil(t, quick.Check(roundTrip, &quick.Config{MaxCount: 500}))
+}
+
+// alphabet contains the characters of random strings, including the ones
+// that need to be escaped in HTML and non-ASCII ones.
+var alphabet = []rune("ab Z09&<>\"'=;#\r\n\t/:.é €\U0001F600")
+
+// randomString returns a random string, which is empty a fourth of the
+// times.
+func randomString(r *rand.Rand) string {
+	if r.Intn(4) == 0 {
+		return ""
+	}
+	return nonEmptyString(r)
+}
+
+func nonEmptyString(r *rand.Rand) string {
+	s := make([]rune, 1+r.Intn(20))
+	for i := range s {
+		s[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(s)
+}
+
+func randomStrings(r *rand.Rand) []string {
+	var s []string
+	for i := r.Intn(3); i > 0; i-- {
+		s = append(s, nonEmptyString(r))
+	}
+	return s
+}
+
+func randomMedia(r *rand.Rand) MediaProperties {
+	return MediaProperties{
+		URL:       nonEmptyString(r),
+		SecureURL: randomString(r),
+		Type:      randomString(r),
+	}
+}
+
+func randomSize(r *rand.Rand) Size {
+	return Size{Width: r.Intn(3000) - 1000, Height: r.Intn(3000)}
+}
@@ -10,36 +10,39 @@ import (
 
 // Object is the representation of a webpage as an object within the graph.
 type Object struct {
-	Title            string
-	Type             string
-	URL              string
-	Description      string
-	Locale           string
-	AlternateLocales []string
-	Determiners      []string
-	SiteName         string
-	Images           []*Image
-	Videos           []*Video
-	Audios           []*Audio
+	Title            string   `json:"title,omitempty"`
+	Type             string   `json:"type,omitempty"`
+	URL              string   `json:"url,omitempty"`
+	Description      string   `json:"description,omitempty"`
+	Locale           string   `json:"locale,omitempty"`
+	AlternateLocales []string `json:"alternate_locales,omitempty"`
+	Determiners      []string `json:"determiners,omitempty"`
+	SiteName         string   `json:"site_name,omitempty"`
+	Images           []*Image `json:"images,omitempty"`
+	Videos           []*Video `json:"videos,omitempty"`
+	Audios           []*Audio `json:"audios,omitempty"`
 }
 
 // MediaProperties defines the properties of an audio, video or image object.
 type MediaProperties struct {
-	URL       string
-	SecureURL string
-	Type      string
+	URL       string `json:"url,omitempty"`
+	SecureURL string `json:"secure_url,omitempty"`
+	Type      string `json:"type,omitempty"`
 }
 
 // Size is the height and width of an media object (video or image).
 type Size struct {
-	Width  int
-	Height int
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
 }
 
 // Image represents an image file to represent the object within the graph.
 type Image struct {
 	MediaProperties
 	Size
+	// Probed reports whether the type and size of the image were read
+	// from the image itself instead of its metatags.
+	Probed bool `json:"probed,omitempty"`
 }
 
 // Video represents a video file to complement the object.
@@ -80,6 +83,22 @@ var (
 	errAudioNotInitialized = errors.New("invalid field: requires og:audio declared before")
 )
 
+// ExtractorName is the name of the OpenGraph Extractor.
+const ExtractorName = "opengraph"
+
+// Extractor builds the OpenGraph object of documents.
+type Extractor struct{}
+
+// Name returns the name of the extractor.
+func (Extractor) Name() string {
+	return ExtractorName
+}
+
+// Extract returns the OpenGraph object of the document.
+func (Extractor) Extract(doc *content.Document) (interface{}, error) {
+	return NewObject(doc.Meta)
+}
+
 // NewObject creates the object representation of the OpenGraph object from
 // the metadata on the webpage.
 func NewObject(meta []*content.Meta) (*Object, error) {
@@ -91,7 +110,7 @@ func NewObject(meta []*content.Meta) (*Object, error) {
 	)
 
 	for _, m := range meta {
-		if !strings.HasPrefix(m.Name, ogPrefix) {
+		if !m.IsNameOrProperty() || !strings.HasPrefix(m.Name, ogPrefix) {
 			continue
 		}
 
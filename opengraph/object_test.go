@@ -1,6 +1,7 @@
 package opengraph
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/mvader/pagecard/content"
@@ -30,6 +31,10 @@ func Test(t *testing.T) {
 			"og:locale:alternate", "es-ca",
 		), nil, &Object{AlternateLocales: []string{"es", "es-ca"}}},
 		{makeMeta("og:site_name", "Foo Site"), nil, &Object{SiteName: "Foo Site"}},
+		{[]*content.Meta{
+			{Name: "og:title", Value: "title", Attr: content.AttrName},
+			{Name: "og:description", Value: "desc", Attr: content.AttrItemprop},
+		}, nil, &Object{Title: "title"}},
 
 		// Image
 		{makeMeta(
@@ -192,3 +197,18 @@ func makeMeta(s ...string) []*content.Meta {
 
 	return meta
 }
+
+func TestNewObjectDuplicateNames(t *testing.T) {
+	assert := assert.New(t)
+	meta, err := content.Parse(strings.NewReader(`<head>
+<meta property="og:image" name="og:image" content="a.png">
+<meta property="og:image:width" content="200">
+</head>`), content.ScopeHead)
+	assert.Nil(err)
+
+	obj, err := NewObject(meta)
+	assert.Nil(err)
+	assert.Equal(obj.Images, []*Image{
+		{MediaProperties: MediaProperties{URL: "a.png"}, Size: Size{Width: 200}},
+	})
+}
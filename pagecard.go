@@ -1,34 +1,246 @@
 package pagecard
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
 	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/oembed"
 	"github.com/mvader/pagecard/opengraph"
 	"github.com/mvader/pagecard/twitter"
 )
 
 // Info contains all the data retrieved from the opengraph and twitter cards
-// metatags in a webpage.
+// metatags in a webpage. Its JSON encoding follows the schema with version
+// SchemaVersion.
 type Info struct {
-	OpenGraph *opengraph.Object
-	Twitter   *twitter.Card
+	// OpenGraph is the OpenGraph object of the webpage, or nil if its
+	// extractor was not run.
+	OpenGraph *opengraph.Object `json:"opengraph,omitempty"`
+	// Twitter is the twitter card of the webpage, or nil if its extractor
+	// was not run.
+	Twitter *twitter.Card `json:"twitter,omitempty"`
+	// Extra contains the data of the extractors other than the OpenGraph
+	// and twitter ones, keyed by their name. When decoded from JSON, it
+	// contains the generic values of encoding/json.
+	Extra map[string]interface{} `json:"extra,omitempty"`
+	// OEmbed is the oEmbed representation of the webpage, if it advertises
+	// an oEmbed endpoint.
+	OEmbed *oembed.Response `json:"oembed,omitempty"`
+	// Fetch describes where the data was retrieved from and the
+	// redirections followed to reach it.
+	Fetch *content.FetchInfo `json:"fetch,omitempty"`
+	// Images contains the image candidates found by the ImagesExtractor,
+	// if it was run.
+	Images []*ImageCandidate `json:"images,omitempty"`
+}
+
+// Options configures how the Info of a webpage is retrieved.
+type Options struct {
+	// Fetcher is used to retrieve the webpage. If nil,
+	// content.DefaultFetcher is used.
+	Fetcher *content.Fetcher
+	// OEmbed configures the requests made to the oEmbed endpoint of the
+	// webpage. If nil, no size constraints are requested.
+	OEmbed *oembed.Options
+	// SkipOEmbed disables the retrieval of oEmbed data.
+	SkipOEmbed bool
+	// Providers is the registry of oEmbed providers used to retrieve the
+	// oEmbed data of the URLs they match straight from their endpoint,
+	// without fetching the webpage. Since the webpage is not fetched, no
+	// extractor is run and no image is probed, so the Info only has its
+	// oEmbed data and a FetchInfo with the URL and no status. If the
+	// request to the endpoint fails, the webpage is fetched instead. If
+	// nil, no registry is used.
+	Providers *oembed.Registry
+	// Extractors are the names of the registered extractors to run. If
+	// nil, all of them are run.
+	Extractors []string
+	// ImageProber is used to read the actual size and type of the
+	// OpenGraph and twitter images of the webpage, which replace the ones
+	// declared in its metatags. If its client is nil, the one of the
+	// fetcher is used, so images are requested with the same restrictions
+	// as the webpage. If nil, images are not probed.
+	ImageProber *content.ImageProber
+	// Cache stores the Info of the webpages retrieved, keyed by their URL
+	// and the options that change it. While the webpage is fresh according
+	// to the fetcher, its Info is returned without fetching or parsing the
+	// webpage again. Once it expires, the Info is reused if the webpage
+	// is revalidated or has the same ETag or Last-Modified, without
+	// extracting it again. Since it is stored as JSON, its Extra data
+	// contains the generic values of encoding/json. It may be the same
+	// cache of the fetcher. If nil, Infos are not cached.
+	Cache content.Cache
 }
 
 // Get retrieves the Info of a webpage with the given URL.
 func Get(url string) (*Info, error) {
-	meta, err := content.Read(url)
+	return GetWithOptions(url, nil)
+}
+
+// GetWithOptions retrieves the Info of a webpage with the given URL using
+// the given options. If opts is nil, the default options are used.
+func GetWithOptions(url string, opts *Options) (*Info, error) {
+	return GetContext(context.Background(), url, opts)
+}
+
+// GetContext is like GetWithOptions, but the requests are canceled if the
+// given context is done before they finish.
+func GetContext(ctx context.Context, url string, opts *Options) (*Info, error) {
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	fetcher := opts.Fetcher
+	if fetcher == nil {
+		fetcher = content.DefaultFetcher
+	}
+
+	extractors, err := selectExtractors(opts.Extractors)
 	if err != nil {
 		return nil, err
 	}
 
-	obj, err := opengraph.NewObject(meta)
+	var (
+		key    string
+		cached *Info
+		entry  *content.CacheEntry
+	)
+
+	if opts.Cache != nil {
+		key = infoKey(url, opts, extractors)
+		cached, entry = cachedInfo(opts.Cache, key)
+		if cached != nil && entry.Fresh(time.Now()) {
+			return cached, nil
+		}
+	}
+
+	if opts.Providers != nil && !opts.SkipOEmbed {
+		if info := getProviderOEmbed(ctx, url, opts.Providers, fetcher.Client, opts.OEmbed); info != nil {
+			return info, nil
+		}
+	}
+
+	doc, err := fetcher.FetchContext(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
-	card, err := twitter.NewCard(meta)
+	// A stale Info is reused if the webpage did not change, so it is not
+	// extracted and its oEmbed data and images are not requested again.
+	if cached != nil && unchanged(entry, doc) {
+		fetch := doc.FetchInfo
+		cached.Fetch = &fetch
+		cacheInfo(opts.Cache, key, cached, doc)
+		return cached, nil
+	}
+
+	info, err := extract(doc, extractors)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Info{obj, card}, nil
+	if !opts.SkipOEmbed {
+		info.OEmbed = getOEmbed(ctx, doc, fetcher.Client, opts.OEmbed)
+	}
+
+	if opts.ImageProber != nil {
+		prober := *opts.ImageProber
+		if prober.Client == nil {
+			prober.Client = fetcher.Client
+		}
+		probeImages(ctx, info, &prober)
+	}
+
+	if opts.Cache != nil {
+		cacheInfo(opts.Cache, key, info, doc)
+	}
+
+	return info, nil
+}
+
+// Parse builds the Info of the webpage read from r. The URL is the one the
+// webpage would be fetched from, and is used to resolve its relative links.
+// Only the scope of the fetcher in the options is used, and the oEmbed data
+// of the webpage is not retrieved. If opts is nil, the default options are
+// used.
+func Parse(r io.Reader, url string, opts *Options) (*Info, error) {
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	fetcher := opts.Fetcher
+	if fetcher == nil {
+		fetcher = content.DefaultFetcher
+	}
+
+	extractors, err := selectExtractors(opts.Extractors)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := content.ParseDocument(r, fetcher.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.URL = url
+	return extract(doc, extractors)
+}
+
+// getOEmbed retrieves the oEmbed data of the document, if it advertises an
+// oEmbed endpoint. Since the oEmbed data is complementary to the metatags
+// of the page, a failure retrieving it is not considered an error and
+// results in no data.
+func getOEmbed(
+	ctx context.Context,
+	doc *content.Document,
+	client *http.Client,
+	opts *oembed.Options,
+) *oembed.Response {
+	endpoint, format, ok := oembed.Discover(doc)
+	if !ok {
+		return nil
+	}
+
+	resp, err := oembed.FetchContext(ctx, client, endpoint, format, opts)
+	if err != nil {
+		return nil
+	}
+
+	return resp
+}
+
+// getProviderOEmbed retrieves the Info of the given URL from the oEmbed
+// endpoint of the provider of the registry matching it, if any. The Info
+// only has the oEmbed data, since the webpage is not fetched.
+func getProviderOEmbed(
+	ctx context.Context,
+	url string,
+	reg *oembed.Registry,
+	client *http.Client,
+	opts *oembed.Options,
+) *Info {
+	endpoint, format, ok := reg.Endpoint(url)
+	if !ok {
+		return nil
+	}
+
+	resp, err := oembed.FetchContext(ctx, client, endpoint, format, opts)
+	if err != nil {
+		return nil
+	}
+
+	return &Info{
+		OEmbed: resp,
+		Fetch:  &content.FetchInfo{URL: url},
+	}
+}
+
+// EffectiveTwitter returns the twitter card as it would be rendered by
+// twitter, using the OpenGraph data of the page for the missing values.
+func (i *Info) EffectiveTwitter() *twitter.Card {
+	return twitter.Effective(i.Twitter, i.OpenGraph)
 }
@@ -0,0 +1,101 @@
+package pagecard
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/oembed"
+	"github.com/mvader/pagecard/twitter"
+	"github.com/stretchr/testify/assert"
+)
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta property="og:title" content="Foo">
+<meta name="twitter:card" content="player">
+<link rel="alternate" type="application/json+oembed" href="/oembed?url=%2Fvideo">
+</head>
+<body><h1>Video</h1></body>
+</html>`
+
+func newTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/video", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, page)
+	})
+	mux.HandleFunc("/oembed", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"type":"video","title":"Foo","width":%q,"height":200}`, r.URL.Query().Get("maxwidth"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGetOEmbed(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	assert := assert.New(t)
+	info, err := GetWithOptions(srv.URL+"/video", &Options{
+		OEmbed: &oembed.Options{MaxWidth: 300},
+	})
+	assert.Nil(err)
+	assert.Equal(info.OpenGraph.Title, "Foo")
+	assert.Equal(info.Fetch.URL, srv.URL+"/video")
+	assert.Equal(info.OEmbed, &oembed.Response{
+		Type:   "video",
+		Title:  "Foo",
+		Width:  300,
+		Height: 200,
+	})
+
+	info, err = GetWithOptions(srv.URL+"/video", &Options{SkipOEmbed: true})
+	assert.Nil(err)
+	assert.Nil(info.OEmbed)
+}
+
+func TestGetProviders(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	assert := assert.New(t)
+	reg, err := oembed.NewRegistry(strings.NewReader(`[{
+		"provider_name": "Foo",
+		"endpoints": [{"schemes": ["` + srv.URL + `/v*"], "url": "` + srv.URL + `/oembed"}]
+	}]`))
+	assert.Nil(err)
+
+	info, err := GetWithOptions(srv.URL+"/video", &Options{Providers: reg})
+	assert.Nil(err)
+	assert.Nil(info.OpenGraph)
+	assert.Nil(info.Twitter)
+	assert.Equal(info.Fetch, &content.FetchInfo{URL: srv.URL + "/video"})
+	assert.Equal(info.OEmbed.Title, "Foo")
+
+	reg.Providers[0].Endpoints[0].URL = srv.URL + "/missing"
+	info, err = GetWithOptions(srv.URL+"/video", &Options{Providers: reg})
+	assert.Nil(err)
+	assert.Equal(info.OpenGraph.Title, "Foo")
+	assert.Equal(info.Fetch.Status, http.StatusOK)
+	assert.Equal(info.OEmbed.Title, "Foo")
+}
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+	info, err := Parse(strings.NewReader(page), "http://foo.bar/video", nil)
+	assert.Nil(err)
+	assert.Equal(info.OpenGraph.Title, "Foo")
+	assert.Equal(info.Twitter.Type, twitter.PlayerCard)
+	assert.Equal(info.Fetch.URL, "http://foo.bar/video")
+	assert.Nil(info.OEmbed)
+
+	info, err = Parse(strings.NewReader(page), "", &Options{Extractors: []string{"opengraph"}})
+	assert.Nil(err)
+	assert.Nil(info.Twitter)
+
+	_, err = Parse(strings.NewReader(page), "", &Options{Extractors: []string{"missing"}})
+	assert.NotNil(err)
+}
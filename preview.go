@@ -0,0 +1,121 @@
+package pagecard
+
+import (
+	"github.com/mvader/pagecard/oembed"
+	"github.com/mvader/pagecard/twitter"
+)
+
+// Preview contains the data needed to render the preview of a webpage. It
+// is the card twitter would render for the webpage, completed with its
+// OpenGraph and oEmbed data.
+type Preview struct {
+	// Type is the kind of card the preview should be rendered as.
+	Type        twitter.CardType `json:"type,omitempty"`
+	URL         string           `json:"url,omitempty"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	SiteName    string           `json:"site_name,omitempty"`
+	Image       *PreviewImage    `json:"image,omitempty"`
+	Player      *PreviewPlayer   `json:"player,omitempty"`
+	// HTML is the markup provided by oEmbed to embed the content of the
+	// webpage, if any.
+	HTML string       `json:"html,omitempty"`
+	App  *twitter.App `json:"app,omitempty"`
+}
+
+// PreviewImage is the image of a preview.
+type PreviewImage struct {
+	URL    string `json:"url"`
+	Alt    string `json:"alt,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// PreviewPlayer is the player of a preview with audio or video.
+type PreviewPlayer struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// Preview returns the preview of the webpage.
+func (i *Info) Preview() *Preview {
+	card := i.EffectiveTwitter()
+	p := &Preview{
+		Type:        card.Type,
+		URL:         card.URL,
+		Title:       card.Title,
+		Description: card.Description,
+		App:         card.App,
+	}
+
+	if card.Image.URL != "" {
+		p.Image = &PreviewImage{
+			URL:    card.Image.URL,
+			Alt:    card.Image.Alt,
+			Width:  card.Image.Width,
+			Height: card.Image.Height,
+		}
+	}
+
+	if card.Player != nil && card.Player.URL != "" {
+		p.Player = &PreviewPlayer{
+			URL:    card.Player.URL,
+			Width:  card.Player.Width,
+			Height: card.Player.Height,
+		}
+	}
+
+	if i.OpenGraph != nil {
+		p.SiteName = i.OpenGraph.SiteName
+	}
+
+	if o := i.OEmbed; o != nil {
+		if p.Title == "" {
+			p.Title = o.Title
+		}
+
+		if p.SiteName == "" {
+			p.SiteName = o.ProviderName
+		}
+
+		if p.Image == nil {
+			switch {
+			case o.Type == "photo" && o.URL != "":
+				p.Image = &PreviewImage{URL: o.URL, Alt: o.Title, Width: o.Width, Height: o.Height}
+			case o.ThumbnailURL != "":
+				p.Image = &PreviewImage{URL: o.ThumbnailURL, Width: o.ThumbnailWidth, Height: o.ThumbnailHeight}
+			}
+		}
+
+		p.HTML = o.HTML
+	}
+
+	if p.SiteName == "" {
+		p.SiteName = card.Site.User
+	}
+
+	if p.URL == "" && i.Fetch != nil {
+		p.URL = i.Fetch.URL
+	}
+
+	if p.Type == 0 {
+		p.Type = previewType(p, i.OEmbed)
+	}
+
+	return p
+}
+
+// previewType returns the kind of card of a preview of a webpage that
+// does not declare one, according to the type of its oEmbed data.
+func previewType(p *Preview, o *oembed.Response) twitter.CardType {
+	switch {
+	case o != nil && o.Type == "video" && o.HTML != "":
+		return twitter.PlayerCard
+	case o != nil && o.Type == "photo" && p.Image != nil:
+		return twitter.SummaryBigPictureCard
+	case p.Title != "":
+		return twitter.SummaryCard
+	}
+	return 0
+}
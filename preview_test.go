@@ -0,0 +1,99 @@
+package pagecard
+
+import (
+	"testing"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/oembed"
+	"github.com/mvader/pagecard/opengraph"
+	"github.com/mvader/pagecard/twitter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreview(t *testing.T) {
+	cases := []struct {
+		name     string
+		info     *Info
+		expected *Preview
+	}{
+		{"empty", &Info{Fetch: &content.FetchInfo{URL: "http://foo.bar"}}, &Preview{URL: "http://foo.bar"}},
+		{
+			"twitter and opengraph",
+			&Info{
+				OpenGraph: &opengraph.Object{
+					Title:       "og title",
+					Description: "og description",
+					URL:         "http://foo.bar/og",
+					SiteName:    "Foo",
+					Images: []*opengraph.Image{{
+						MediaProperties: opengraph.MediaProperties{URL: "http://foo.bar/og.png"},
+						Size:            opengraph.Size{Width: 1200, Height: 630},
+					}},
+				},
+				Twitter: &twitter.Card{
+					Type:   twitter.PlayerCard,
+					Title:  "twitter title",
+					Site:   twitter.Site{User: "@foo"},
+					Image:  twitter.Image{Alt: "alt"},
+					Player: &twitter.Player{URL: "https://foo.bar/player", Width: 640, Height: 360},
+				},
+				OEmbed: &oembed.Response{Type: "video", Title: "oembed title", HTML: "<iframe></iframe>"},
+				Fetch:  &content.FetchInfo{URL: "http://foo.bar/final"},
+			},
+			&Preview{
+				Type:        twitter.PlayerCard,
+				URL:         "http://foo.bar/og",
+				Title:       "twitter title",
+				Description: "og description",
+				SiteName:    "Foo",
+				Image:       &PreviewImage{"http://foo.bar/og.png", "alt", 1200, 630},
+				Player:      &PreviewPlayer{"https://foo.bar/player", 640, 360},
+				HTML:        "<iframe></iframe>",
+			},
+		},
+		{
+			"oembed photo",
+			&Info{
+				OEmbed: &oembed.Response{
+					Type:         "photo",
+					Title:        "Photo",
+					ProviderName: "Flickr",
+					URL:          "http://foo.bar/photo.jpg",
+					Width:        800,
+					Height:       600,
+				},
+				Fetch: &content.FetchInfo{URL: "http://foo.bar/photo"},
+			},
+			&Preview{
+				Type:     twitter.SummaryBigPictureCard,
+				URL:      "http://foo.bar/photo",
+				Title:    "Photo",
+				SiteName: "Flickr",
+				Image:    &PreviewImage{"http://foo.bar/photo.jpg", "Photo", 800, 600},
+			},
+		},
+		{
+			"oembed video",
+			&Info{
+				Twitter: &twitter.Card{Site: twitter.Site{User: "@foo"}},
+				OEmbed: &oembed.Response{
+					Type:           "video",
+					HTML:           "<iframe></iframe>",
+					ThumbnailURL:   "http://foo.bar/thumb.jpg",
+					ThumbnailWidth: 480,
+				},
+			},
+			&Preview{
+				Type:     twitter.PlayerCard,
+				SiteName: "@foo",
+				Image:    &PreviewImage{URL: "http://foo.bar/thumb.jpg", Width: 480},
+				HTML:     "<iframe></iframe>",
+			},
+		},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		assert.Equal(c.info.Preview(), c.expected, c.name)
+	}
+}
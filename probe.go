@@ -0,0 +1,112 @@
+package pagecard
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"github.com/mvader/pagecard/content"
+)
+
+// maxConcurrentProbes is the maximum number of images of a webpage probed
+// at the same time.
+const maxConcurrentProbes = 4
+
+// probeImages replaces the size and type of the OpenGraph and twitter
+// images of the info with the ones read from the images themselves. Every
+// image is probed once, up to maxConcurrentProbes at the same time, and
+// the ones that cannot be probed keep their declared values.
+func probeImages(ctx context.Context, info *Info, prober *content.ImageProber) {
+	var base *url.URL
+	if info.Fetch != nil {
+		base, _ = url.Parse(info.Fetch.URL)
+	}
+
+	var urls []string
+	probed := make(map[string]*content.ImageInfo)
+	add := func(rawurl string) {
+		u := resolveImageURL(base, rawurl)
+		if _, ok := probed[u]; u != "" && !ok {
+			probed[u] = nil
+			urls = append(urls, u)
+		}
+	}
+
+	if og := info.OpenGraph; og != nil {
+		for _, img := range og.Images {
+			add(img.URL)
+		}
+	}
+
+	if info.Twitter != nil {
+		add(info.Twitter.Image.URL)
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mut   sync.Mutex
+		slots = make(chan struct{}, maxConcurrentProbes)
+	)
+	for _, u := range urls {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(u string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			img, err := prober.Probe(ctx, u)
+			if err != nil {
+				return
+			}
+
+			mut.Lock()
+			probed[u] = img
+			mut.Unlock()
+		}(u)
+	}
+	wg.Wait()
+
+	if og := info.OpenGraph; og != nil {
+		for _, img := range og.Images {
+			if p := probed[resolveImageURL(base, img.URL)]; p != nil {
+				img.Type = p.Type
+				img.Width = p.Width
+				img.Height = p.Height
+				img.Probed = true
+			}
+		}
+	}
+
+	if card := info.Twitter; card != nil {
+		if p := probed[resolveImageURL(base, card.Image.URL)]; p != nil {
+			card.Image.Width = p.Width
+			card.Image.Height = p.Height
+			card.Image.Probed = true
+		}
+	}
+}
+
+// resolveImageURL returns the absolute URL of an image, resolved against
+// the URL of the webpage, or an empty string if it is not an HTTP URL.
+func resolveImageURL(base *url.URL, rawurl string) string {
+	if rawurl == "" {
+		return ""
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+
+	return u.String()
+}
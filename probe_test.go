@@ -0,0 +1,129 @@
+package pagecard
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/opengraph"
+	"github.com/mvader/pagecard/twitter"
+	"github.com/stretchr/testify/assert"
+)
+
+const imagesPage = `<html><head>
+<meta property="og:image" content="/a.png">
+<meta property="og:image:width" content="1000">
+<meta property="og:image:height" content="1000">
+<meta property="og:image" content="/missing.png">
+<meta property="og:image:width" content="50">
+<meta name="twitter:image" content="%s/a.png">
+</head></html>`
+
+func TestGetProbeImages(t *testing.T) {
+	var buf bytes.Buffer
+	assert := assert.New(t)
+	assert.Nil(png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 300, 200))))
+
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, imagesPage, "http://"+r.Host)
+	})
+	mux.HandleFunc("/a.png", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(buf.Bytes())
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	info, err := GetWithOptions(srv.URL+"/page", &Options{
+		SkipOEmbed:  true,
+		ImageProber: new(content.ImageProber),
+	})
+	assert.Nil(err)
+	assert.Equal(requests, 1)
+	assert.Equal(info.OpenGraph.Images, []*opengraph.Image{
+		{
+			MediaProperties: opengraph.MediaProperties{URL: "/a.png", Type: "image/png"},
+			Size:            opengraph.Size{Width: 300, Height: 200},
+			Probed:          true,
+		},
+		{
+			MediaProperties: opengraph.MediaProperties{URL: "/missing.png"},
+			Size:            opengraph.Size{Width: 50},
+		},
+	})
+	assert.Equal(info.Twitter.Image, twitter.Image{URL: srv.URL + "/a.png", Width: 300, Height: 200, Probed: true})
+
+	info, err = GetWithOptions(srv.URL+"/page", &Options{SkipOEmbed: true})
+	assert.Nil(err)
+	assert.Equal(info.OpenGraph.Images[0].Width, 1000)
+	assert.False(info.OpenGraph.Images[0].Probed)
+}
+
+// headerTransport sets a header in all the requests it makes.
+type headerTransport struct {
+	key, value string
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(t.key, t.value)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestGetProbeImagesClient(t *testing.T) {
+	var (
+		mut      sync.Mutex
+		inFlight int
+		max      int
+		headers  []string
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 20; i++ {
+			fmt.Fprintf(w, `<meta property="og:image" content="/%d.png">`, i)
+		}
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		inFlight++
+		if inFlight > max {
+			max = inFlight
+		}
+		headers = append(headers, r.Header.Get("X-Client"))
+		mut.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+		http.NotFound(w, r)
+
+		mut.Lock()
+		inFlight--
+		mut.Unlock()
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := &http.Client{Transport: headerTransport{"X-Client", "fetcher"}}
+	_, err := GetWithOptions(srv.URL+"/page", &Options{
+		Fetcher:     &content.Fetcher{Client: client},
+		SkipOEmbed:  true,
+		ImageProber: new(content.ImageProber),
+	})
+
+	assert := assert.New(t)
+	assert.Nil(err)
+	assert.Equal(len(headers), 20)
+	for _, h := range headers {
+		assert.Equal(h, "fetcher")
+	}
+	assert.True(max <= maxConcurrentProbes)
+}
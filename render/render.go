@@ -0,0 +1,147 @@
+// Package render renders the previews of webpages as embeddable HTML
+// cards.
+//
+// A Theme has a template for every kind of twitter card, named after the
+// card type: "summary", "summary_large_image", "player" and "app". The
+// templates are executed with the Preview of the webpage, and previews
+// without a card type are rendered with "summary". All of them can be
+// overridden, as well as the "image" and "text" templates they share.
+//
+// The player of a "player" card is framed with the flags of the "sandbox"
+// template, which allow its scripts but not allow-same-origin, since the
+// player URL comes from the webpage and a framed document with both flags
+// can remove its own sandbox. Override it to allow more for trusted
+// players.
+//
+// The templates are html/template templates, so all the strings coming
+// from the webpage are escaped according to their context, and URLs with
+// schemes other than http, https and mailto are replaced. The oEmbed
+// markup of the preview is not rendered by the default templates, since
+// it comes from a third party.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+
+	"github.com/mvader/pagecard"
+	"github.com/mvader/pagecard/twitter"
+)
+
+// ErrNoPreview is returned when there is no preview to render.
+var ErrNoPreview = errors.New("render: no preview")
+
+const defaultType = twitter.SummaryCard
+
+// Theme is a set of templates to render previews.
+type Theme struct {
+	tmpl *template.Template
+}
+
+var defaultTheme = New()
+
+// New returns a theme with the default templates.
+func New() *Theme {
+	t := template.New("pagecard").Funcs(template.FuncMap{
+		"host":   host,
+		"stores": stores,
+	})
+	return &Theme{template.Must(t.Parse(defaultTemplates))}
+}
+
+// Parse parses the given templates into the theme, replacing the ones
+// with the same names. It must be called before the theme renders any
+// preview.
+func (t *Theme) Parse(text string) (*Theme, error) {
+	if _, err := t.tmpl.Parse(text); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render writes the card of the given preview to w.
+func (t *Theme) Render(w io.Writer, p *pagecard.Preview) error {
+	if p == nil {
+		return ErrNoPreview
+	}
+
+	typ := p.Type
+	if typ.String() == "" {
+		typ = defaultType
+	}
+
+	tmpl := t.tmpl.Lookup(typ.String())
+	if tmpl == nil {
+		return fmt.Errorf("render: no template for card type: %s", typ)
+	}
+
+	return tmpl.Execute(w, p)
+}
+
+// RenderInfo writes the card of the preview of the given info to w.
+func (t *Theme) RenderInfo(w io.Writer, info *pagecard.Info) error {
+	if info == nil {
+		return ErrNoPreview
+	}
+	return t.Render(w, info.Preview())
+}
+
+// Render writes the card of the given preview to w with the default
+// theme.
+func Render(w io.Writer, p *pagecard.Preview) error {
+	return defaultTheme.Render(w, p)
+}
+
+// RenderInfo writes the card of the preview of the given info to w with
+// the default theme.
+func RenderInfo(w io.Writer, info *pagecard.Info) error {
+	return defaultTheme.RenderInfo(w, info)
+}
+
+// host returns the host of the given URL, or an empty string if it is not
+// a valid absolute URL.
+func host(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// Store is the page of an app in an app store.
+type Store struct {
+	Name string
+	URL  string
+}
+
+// stores returns the pages of the app in the App Store and Google Play.
+func stores(app *twitter.App) []Store {
+	if app == nil {
+		return nil
+	}
+
+	var result []Store
+	if id := app.IPhone.ID; id != "" {
+		result = append(result, Store{"App Store", appStoreURL(id, app.Country)})
+	} else if id := app.IPad.ID; id != "" {
+		result = append(result, Store{"App Store", appStoreURL(id, app.Country)})
+	}
+
+	if id := app.GooglePlay.ID; id != "" {
+		u := "https://play.google.com/store/apps/details?id=" + url.QueryEscape(id)
+		result = append(result, Store{"Google Play", u})
+	}
+
+	return result
+}
+
+func appStoreURL(id, country string) string {
+	u := "https://apps.apple.com/"
+	if country != "" {
+		u += url.PathEscape(country) + "/"
+	}
+	return u + "app/id" + url.PathEscape(id)
+}
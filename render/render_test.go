@@ -0,0 +1,104 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mvader/pagecard"
+	"github.com/mvader/pagecard/twitter"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPreview(typ twitter.CardType) *pagecard.Preview {
+	return &pagecard.Preview{
+		Type:        typ,
+		URL:         "http://foo.bar/baz",
+		Title:       "Foo",
+		Description: "Bar",
+		SiteName:    "Baz",
+		Image:       &pagecard.PreviewImage{URL: "http://foo.bar/img.png", Alt: "Image", Width: 600},
+		Player:      &pagecard.PreviewPlayer{URL: "https://foo.bar/player", Height: 300},
+		App: &twitter.App{
+			IPhone:     twitter.AppInfo{ID: "123"},
+			GooglePlay: twitter.AppInfo{ID: "bar.foo"},
+			Country:    "us",
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	const text = `<div class="pagecard-text"><div class="pagecard-site">Baz</div><div class="pagecard-title">Foo</div><div class="pagecard-description">Bar</div><div class="pagecard-domain">foo.bar</div></div>`
+	const image = `<img class="pagecard-image" src="http://foo.bar/img.png" alt="Image" width="600" loading="lazy">`
+
+	cases := []struct {
+		typ      twitter.CardType
+		expected string
+	}{
+		{0, `<a class="pagecard pagecard-summary" href="http://foo.bar/baz" target="_blank" rel="noopener nofollow">` + image + text + `</a>`},
+		{twitter.SummaryCard, `<a class="pagecard pagecard-summary" href="http://foo.bar/baz" target="_blank" rel="noopener nofollow">` + image + text + `</a>`},
+		{twitter.SummaryBigPictureCard, `<a class="pagecard pagecard-large-image" href="http://foo.bar/baz" target="_blank" rel="noopener nofollow">` + image + text + `</a>`},
+		{twitter.PlayerCard, `<div class="pagecard pagecard-player"><iframe class="pagecard-frame" src="https://foo.bar/player" sandbox="allow-scripts allow-popups allow-presentation" allowfullscreen height="300"></iframe><a href="http://foo.bar/baz" target="_blank" rel="noopener nofollow">` + text + `</a></div>`},
+		{twitter.AppCard, `<div class="pagecard pagecard-app">` + image + text + `<ul class="pagecard-stores"><li><a href="https://apps.apple.com/us/app/id123" target="_blank" rel="noopener nofollow">App Store</a></li><li><a href="https://play.google.com/store/apps/details?id=bar.foo" target="_blank" rel="noopener nofollow">Google Play</a></li></ul></div>`},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		var buf bytes.Buffer
+		assert.Nil(Render(&buf, newPreview(c.typ)))
+		assert.Equal(buf.String(), c.expected)
+	}
+
+	assert.Equal(Render(new(bytes.Buffer), nil), ErrNoPreview)
+	assert.Equal(RenderInfo(new(bytes.Buffer), nil), ErrNoPreview)
+}
+
+func TestRenderEscaping(t *testing.T) {
+	p := &pagecard.Preview{
+		Type:        twitter.PlayerCard,
+		URL:         "javascript:alert(1)",
+		Title:       `<script>alert("title")</script>`,
+		Description: `" onmouseover="alert(1)`,
+		Image:       &pagecard.PreviewImage{URL: "data:text/html,<script>", Alt: `"><script>`},
+		HTML:        "<script>alert(1)</script>",
+	}
+
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	assert.Nil(Render(&buf, p))
+	assert.Equal(buf.String(), `<div class="pagecard pagecard-player">`+
+		`<img class="pagecard-image" src="#ZgotmplZ" alt="&#34;&gt;&lt;script&gt;" loading="lazy">`+
+		`<a href="#ZgotmplZ" target="_blank" rel="noopener nofollow"><div class="pagecard-text">`+
+		`<div class="pagecard-title">&lt;script&gt;alert(&#34;title&#34;)&lt;/script&gt;</div>`+
+		`<div class="pagecard-description">&#34; onmouseover=&#34;alert(1)</div>`+
+		`</div></a></div>`)
+}
+
+func TestThemeParse(t *testing.T) {
+	assert := assert.New(t)
+	theme, err := New().Parse(`{{define "text"}}<b>{{.Title}}</b>{{end}}`)
+	assert.Nil(err)
+
+	p := newPreview(twitter.SummaryCard)
+	p.Image = nil
+	var buf bytes.Buffer
+	assert.Nil(theme.Render(&buf, p))
+	assert.Equal(buf.String(), `<a class="pagecard pagecard-summary" href="http://foo.bar/baz" target="_blank" rel="noopener nofollow"><b>Foo</b></a>`)
+
+	buf.Reset()
+	assert.Nil(theme.RenderInfo(&buf, &pagecard.Info{Twitter: &twitter.Card{Type: twitter.AppCard, Title: "App"}}))
+	assert.Equal(buf.String(), `<div class="pagecard pagecard-app"><b>App</b></div>`)
+
+	buf.Reset()
+	assert.Nil(Render(&buf, p))
+	assert.Contains(buf.String(), `<div class="pagecard-title">Foo</div>`)
+
+	theme, err = New().Parse(`{{define "sandbox"}}allow-scripts allow-same-origin{{end}}`)
+	assert.Nil(err)
+
+	buf.Reset()
+	assert.Nil(theme.Render(&buf, newPreview(twitter.PlayerCard)))
+	assert.Contains(buf.String(), `sandbox="allow-scripts allow-same-origin"`)
+
+	_, err = New().Parse(`{{define "summary"}}{{.Missing`)
+	assert.NotNil(err)
+}
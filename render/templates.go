@@ -0,0 +1,50 @@
+package render
+
+const defaultTemplates = `
+{{- define "image" -}}
+{{with .Image}}<img class="pagecard-image" src="{{.URL}}" alt="{{.Alt}}"
+{{- with .Width}} width="{{.}}"{{end}}{{with .Height}} height="{{.}}"{{end}} loading="lazy">{{end}}
+{{- end -}}
+
+{{- define "text" -}}
+<div class="pagecard-text">
+{{- with .SiteName}}<div class="pagecard-site">{{.}}</div>{{end}}
+{{- with .Title}}<div class="pagecard-title">{{.}}</div>{{end}}
+{{- with .Description}}<div class="pagecard-description">{{.}}</div>{{end}}
+{{- with host .URL}}<div class="pagecard-domain">{{.}}</div>{{end -}}
+</div>
+{{- end -}}
+
+{{- define "summary" -}}
+<a class="pagecard pagecard-summary" href="{{.URL}}" target="_blank" rel="noopener nofollow">
+{{- template "image" .}}{{template "text" . -}}
+</a>
+{{- end -}}
+
+{{- define "summary_large_image" -}}
+<a class="pagecard pagecard-large-image" href="{{.URL}}" target="_blank" rel="noopener nofollow">
+{{- template "image" .}}{{template "text" . -}}
+</a>
+{{- end -}}
+
+{{- define "sandbox" -}}
+allow-scripts allow-popups allow-presentation
+{{- end -}}
+
+{{- define "player" -}}
+<div class="pagecard pagecard-player">
+{{- with .Player}}<iframe class="pagecard-frame" src="{{.URL}}" sandbox="{{template "sandbox" .}}" allowfullscreen
+{{- with .Width}} width="{{.}}"{{end}}{{with .Height}} height="{{.}}"{{end}}></iframe>
+{{- else}}{{template "image" .}}{{end -}}
+<a href="{{.URL}}" target="_blank" rel="noopener nofollow">{{template "text" .}}</a></div>
+{{- end -}}
+
+{{- define "app" -}}
+<div class="pagecard pagecard-app">
+{{- template "image" .}}{{template "text" . -}}
+{{with stores .App}}<ul class="pagecard-stores">
+{{- range .}}<li><a href="{{.URL}}" target="_blank" rel="noopener nofollow">{{.Name}}</a></li>{{end -}}
+</ul>{{end -}}
+</div>
+{{- end -}}
+`
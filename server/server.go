@@ -0,0 +1,198 @@
+// Package server provides an HTTP service that retrieves the previews of
+// webpages.
+//
+// The service has the following endpoints:
+//
+//	GET /unfurl?url=<url>
+//		Returns the Info of the webpage as JSON. With format=preview, its
+//		Preview is returned instead.
+//	GET /healthz
+//		Returns 200 while the service is running.
+//
+// Errors are returned as JSON objects with an "error" field.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mvader/pagecard"
+	"github.com/mvader/pagecard/content"
+)
+
+const (
+	// DefaultTimeout is the maximum time to retrieve a webpage if the
+	// options do not specify one.
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxConcurrent is the maximum number of webpages retrieved at
+	// the same time if the options do not specify one.
+	DefaultMaxConcurrent = 64
+	// DefaultCacheEntries is the maximum number of pages kept in the cache
+	// of the default fetcher.
+	DefaultCacheEntries = 10000
+	// DefaultCacheBytes is the maximum size of the pages kept in the cache
+	// of the default fetcher.
+	DefaultCacheBytes = 256 << 20
+	// DefaultMaxBodyBytes is the maximum number of bytes of a page read by
+	// the default fetcher.
+	DefaultMaxBodyBytes = 2 << 20
+)
+
+// Options configures the service.
+type Options struct {
+	// Options configures how the webpages are retrieved. Its fetcher is
+	// shared by all the requests, so they share its cache and concurrent
+	// requests for the same webpage are coalesced. If its fetcher is nil,
+	// one with a MemoryCache, a limit of DefaultMaxBodyBytes and a client
+	// created with content.NewSafeClient is used, and its cache also
+	// stores the Infos if no cache is given. A given fetcher is used
+	// as is, so it should be configured the same way if the URLs come from
+	// untrusted users.
+	pagecard.Options
+	// AllowPrivate allows the default fetcher to request private, loopback
+	// and link-local addresses, which are refused otherwise so users of
+	// the service cannot reach the internal network. It has no effect if
+	// a fetcher is given.
+	AllowPrivate bool
+	// Timeout is the maximum time to retrieve a webpage, including the
+	// time waiting for a free slot. If zero, DefaultTimeout is used.
+	Timeout time.Duration
+	// MaxConcurrent is the maximum number of webpages retrieved at the
+	// same time. Requests over the limit wait for a free slot until they
+	// time out. If zero, DefaultMaxConcurrent is used.
+	MaxConcurrent int
+}
+
+// Server is the http.Handler of the service.
+type Server struct {
+	opts  pagecard.Options
+	slots chan struct{}
+	mux   *http.ServeMux
+	// timeout is the maximum time to retrieve a webpage.
+	timeout time.Duration
+}
+
+// New returns the Server of the service with the given options. If opts is
+// nil, the default options are used.
+func New(opts *Options) *Server {
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	s := &Server{
+		opts:    opts.Options,
+		timeout: opts.Timeout,
+		mux:     http.NewServeMux(),
+	}
+
+	if s.timeout <= 0 {
+		s.timeout = DefaultTimeout
+	}
+
+	max := opts.MaxConcurrent
+	if max <= 0 {
+		max = DefaultMaxConcurrent
+	}
+	s.slots = make(chan struct{}, max)
+
+	if s.opts.Fetcher == nil {
+		s.opts.Fetcher = &content.Fetcher{
+			MaxRefreshes:    content.DefaultFetcher.MaxRefreshes,
+			MaxRefreshDelay: content.DefaultFetcher.MaxRefreshDelay,
+			MaxBodyBytes:    DefaultMaxBodyBytes,
+			Cache:           content.NewMemoryCache(DefaultCacheEntries, DefaultCacheBytes),
+		}
+
+		if s.opts.Cache == nil {
+			s.opts.Cache = s.opts.Fetcher.Cache
+		}
+
+		if !opts.AllowPrivate {
+			s.opts.Fetcher.Client = content.NewSafeClient(nil)
+		}
+	}
+
+	s.mux.HandleFunc("/unfurl", s.unfurl)
+	s.mux.HandleFunc("/healthz", s.healthz)
+	return s
+}
+
+// ServeHTTP handles the requests to the service.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) unfurl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	query := r.URL.Query()
+	rawurl := query.Get("url")
+	if u, err := url.Parse(rawurl); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		writeError(w, http.StatusBadRequest, errors.New("url must be an absolute http or https URL"))
+		return
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "info" && format != "preview" {
+		writeError(w, http.StatusBadRequest, errors.New("format must be info or preview"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, errors.New("too many requests in flight"))
+		return
+	}
+
+	info, err := pagecard.GetContext(ctx, rawurl, &s.opts)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	if format == "preview" {
+		writeJSON(w, http.StatusOK, info.Preview())
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// errorStatus returns the status code of the response to a request that
+// failed retrieving its webpage with the given error.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, content.ErrForbiddenAddress), errors.Is(err, content.ErrDisallowedByRobots):
+		return http.StatusForbidden
+	}
+	return http.StatusBadGateway
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
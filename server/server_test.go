@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mvader/pagecard"
+	"github.com/mvader/pagecard/content"
+	"github.com/stretchr/testify/assert"
+)
+
+const page = `<head>
+<meta property="og:title" content="Foo">
+<meta property="og:site_name" content="Bar">
+<meta property="og:image" content="http://foo.bar/a.png">
+<meta name="twitter:card" content="summary_large_image">
+</head>`
+
+func newPageServer() (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, page)
+	}))
+	return srv, &requests
+}
+
+func get(s http.Handler, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+
+	var body map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec, body
+}
+
+func unfurlPath(u string, params ...string) string {
+	path := "/unfurl?url=" + url.QueryEscape(u)
+	for i := 0; i < len(params); i += 2 {
+		path += "&" + params[i] + "=" + params[i+1]
+	}
+	return path
+}
+
+func TestUnfurl(t *testing.T) {
+	pages, requests := newPageServer()
+	defer pages.Close()
+
+	s := New(&Options{Options: pagecard.Options{SkipOEmbed: true}, AllowPrivate: true})
+
+	assert := assert.New(t)
+	rec, body := get(s, unfurlPath(pages.URL+"/article"))
+	assert.Equal(rec.Code, http.StatusOK)
+	assert.Equal(rec.Header().Get("Content-Type"), "application/json; charset=utf-8")
+
+	var info pagecard.Info
+	assert.Nil(json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.Equal(info.OpenGraph.Title, "Foo")
+	assert.Equal(info.Fetch.URL, pages.URL+"/article")
+	assert.Equal(body["schema_version"], float64(pagecard.SchemaVersion))
+	assert.Equal(body["twitter"], map[string]interface{}{"type": "summary_large_image"})
+
+	rec, _ = get(s, unfurlPath(pages.URL+"/article", "format", "preview"))
+	assert.Equal(rec.Code, http.StatusOK)
+
+	var preview pagecard.Preview
+	assert.Nil(json.Unmarshal(rec.Body.Bytes(), &preview))
+	assert.Equal(preview.Title, "Foo")
+	assert.Equal(preview.SiteName, "Bar")
+	assert.Equal(preview.Image.URL, "http://foo.bar/a.png")
+
+	// The second request is served from the shared cache.
+	assert.Equal(atomic.LoadInt32(requests), int32(1))
+}
+
+func TestUnfurlErrors(t *testing.T) {
+	pages, _ := newPageServer()
+	defer pages.Close()
+
+	s := New(&Options{
+		Options: pagecard.Options{
+			SkipOEmbed: true,
+			Fetcher:    &content.Fetcher{Robots: new(content.Robots)},
+		},
+		Timeout: 50 * time.Millisecond,
+	})
+
+	cases := []struct {
+		path   string
+		status int
+		err    string
+	}{
+		{"/unfurl", http.StatusBadRequest, "url must be an absolute http or https URL"},
+		{unfurlPath("/relative"), http.StatusBadRequest, "url must be an absolute http or https URL"},
+		{unfurlPath("ftp://foo.bar/"), http.StatusBadRequest, "url must be an absolute http or https URL"},
+		{unfurlPath(pages.URL, "format", "xml"), http.StatusBadRequest, "format must be info or preview"},
+		{unfurlPath(pages.URL + "/private"), http.StatusForbidden, content.ErrDisallowedByRobots.Error()},
+		{unfurlPath(pages.URL + "/slow"), http.StatusGatewayTimeout, ""},
+		{unfurlPath("http://127.0.0.1:1/"), http.StatusBadGateway, ""},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		rec, body := get(s, c.path)
+		assert.Equal(rec.Code, c.status, c.path)
+		assert.NotEmpty(body["error"], c.path)
+		if c.err != "" {
+			assert.Equal(body["error"], c.err, c.path)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("POST", unfurlPath(pages.URL), nil))
+	assert.Equal(rec.Code, http.StatusMethodNotAllowed)
+	assert.Equal(rec.Header().Get("Allow"), "GET, HEAD")
+}
+
+func TestUnfurlPrivate(t *testing.T) {
+	pages, requests := newPageServer()
+	defer pages.Close()
+
+	assert := assert.New(t)
+	rec, body := get(New(nil), unfurlPath(pages.URL))
+	assert.Equal(rec.Code, http.StatusForbidden)
+	assert.Equal(body["error"], content.ErrForbiddenAddress.Error())
+	assert.Equal(atomic.LoadInt32(requests), int32(0))
+}
+
+func TestUnfurlConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	pages := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/blocked" {
+			<-release
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer pages.Close()
+
+	s := New(&Options{
+		Options:       pagecard.Options{SkipOEmbed: true},
+		Timeout:       100 * time.Millisecond,
+		MaxConcurrent: 1,
+		AllowPrivate:  true,
+	})
+
+	done := make(chan struct{})
+	go func() {
+		get(s, unfurlPath(pages.URL+"/blocked"))
+		close(done)
+	}()
+
+	// Wait for the blocked request to take the only slot.
+	time.Sleep(10 * time.Millisecond)
+
+	// The request gives up waiting for a slot before the blocked one times
+	// out.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", unfurlPath(pages.URL+"/other"), nil).WithContext(ctx))
+
+	assert := assert.New(t)
+	assert.Equal(rec.Code, http.StatusServiceUnavailable)
+	assert.Equal(rec.Header().Get("Retry-After"), "1")
+	assert.Contains(rec.Body.String(), "too many requests in flight")
+
+	close(release)
+	<-done
+}
+
+func TestHealthz(t *testing.T) {
+	assert := assert.New(t)
+	rec, body := get(New(nil), "/healthz")
+	assert.Equal(rec.Code, http.StatusOK)
+	assert.Equal(body["status"], "ok")
+}
@@ -0,0 +1,341 @@
+// Package thumbnail generates small previews of images, such as the image
+// selected for a webpage, to store them for offline display. It only uses
+// the image packages of the standard library, so it decodes PNG, JPEG and
+// GIF images, of which only the first frame is used, and encodes JPEG and
+// PNG thumbnails.
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/mvader/pagecard/content"
+)
+
+var (
+	// ErrTooLarge is returned when an image exceeds the maximum size in
+	// bytes or pixels.
+	ErrTooLarge = errors.New("thumbnail: image too large")
+	// ErrUnsupportedFormat is returned when an image is not a PNG, JPEG or
+	// GIF image.
+	ErrUnsupportedFormat = errors.New("thumbnail: unsupported image format")
+	// ErrNoSize is returned when the options do not specify the size of
+	// the thumbnail.
+	ErrNoSize = errors.New("thumbnail: no width or height")
+)
+
+const (
+	// DefaultMaxBytes is the maximum size in bytes of an image if the
+	// options do not specify one.
+	DefaultMaxBytes = 10 << 20
+	// DefaultMaxPixels is the maximum number of pixels of an image if the
+	// options do not specify one. It bounds the memory used to decode it.
+	DefaultMaxPixels = 40 << 20
+	// DefaultQuality is the quality of JPEG thumbnails if the options do
+	// not specify one.
+	DefaultQuality = 85
+)
+
+// Format is the format of a thumbnail.
+type Format byte
+
+const (
+	// JPEG is the format of thumbnails encoded as JPEG. Transparent areas
+	// are rendered over white.
+	JPEG Format = 1 << iota
+	// PNG is the format of thumbnails encoded as PNG.
+	PNG
+)
+
+// Options configures how thumbnails are generated.
+type Options struct {
+	// Width and Height are the size of the thumbnail. If one of them is
+	// zero, it is computed from the other one and the aspect ratio of the
+	// image. Images are never enlarged, so the thumbnails of smaller
+	// images are smaller than requested.
+	Width  int
+	Height int
+	// Crop makes the thumbnail have exactly the requested size, cropping
+	// the center of the image. Otherwise the whole image is resized to fit
+	// in the requested size, keeping its aspect ratio.
+	Crop bool
+	// Format is the format of the thumbnail. If zero, JPEG is used.
+	Format Format
+	// Quality is the quality of JPEG thumbnails, from 1 to 100. If zero,
+	// DefaultQuality is used.
+	Quality int
+	// MaxBytes is the maximum size in bytes of the image. If zero,
+	// DefaultMaxBytes is used.
+	MaxBytes int64
+	// MaxPixels is the maximum number of pixels of the image, which is
+	// checked before decoding it. If zero, DefaultMaxPixels is used.
+	MaxPixels int
+}
+
+// Thumbnail is an encoded thumbnail.
+type Thumbnail struct {
+	Data []byte
+	// Type is the MIME type of the thumbnail.
+	Type   string
+	Width  int
+	Height int
+}
+
+// Fetch downloads the image with the given URL using the given client and
+// generates its thumbnail. If the client is nil, http.DefaultClient is
+// used, which connects to any address; image URLs taken from untrusted
+// pages should be downloaded with a client like content.NewSafeClient.
+func Fetch(ctx context.Context, client *http.Client, url string, opts *Options) (*Thumbnail, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &content.StatusError{Code: resp.StatusCode}
+	}
+
+	if resp.ContentLength > maxBytes(opts) {
+		return nil, ErrTooLarge
+	}
+
+	return Generate(resp.Body, opts)
+}
+
+// Generate generates the thumbnail of the image read from r.
+func Generate(r io.Reader, opts *Options) (*Thumbnail, error) {
+	if opts == nil || (opts.Width <= 0 && opts.Height <= 0) {
+		return nil, ErrNoSize
+	}
+
+	data, err := readAll(r, maxBytes(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	maxPixels := opts.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+
+	img, err := decode(data, maxPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	thumb := resize(img, opts.Width, opts.Height, opts.Crop)
+	return encode(thumb, opts)
+}
+
+func maxBytes(opts *Options) int64 {
+	if opts == nil || opts.MaxBytes <= 0 {
+		return DefaultMaxBytes
+	}
+	return opts.MaxBytes
+}
+
+// readAll reads r until EOF, failing with ErrTooLarge if it is longer than
+// max bytes.
+func readAll(r io.Reader, max int64) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(io.LimitReader(r, max+1)); err != nil {
+		return nil, err
+	}
+
+	if int64(buf.Len()) > max {
+		return nil, ErrTooLarge
+	}
+	return buf.Bytes(), nil
+}
+
+type decoder struct {
+	magic        string
+	decodeConfig func(io.Reader) (image.Config, error)
+	decode       func(io.Reader) (image.Image, error)
+}
+
+var decoders = []decoder{
+	{"\x89PNG\r\n\x1a\n", png.DecodeConfig, png.Decode},
+	{"\xff\xd8", jpeg.DecodeConfig, jpeg.Decode},
+	{"GIF8", gif.DecodeConfig, gif.Decode},
+}
+
+// decode decodes the image, after checking it does not have more than
+// maxPixels pixels.
+func decode(data []byte, maxPixels int) (image.Image, error) {
+	for _, d := range decoders {
+		if !bytes.HasPrefix(data, []byte(d.magic)) {
+			continue
+		}
+
+		cfg, err := d.decodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Width <= 0 || cfg.Height <= 0 {
+			return nil, fmt.Errorf("thumbnail: invalid image size: %dx%d", cfg.Width, cfg.Height)
+		}
+
+		if cfg.Width > maxPixels/cfg.Height {
+			return nil, ErrTooLarge
+		}
+
+		return d.decode(bytes.NewReader(data))
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+// resize returns the image resized to the given size. See Options for how
+// the size is computed.
+func resize(img image.Image, width, height int, crop bool) *image.RGBA {
+	b := img.Bounds()
+	sw, sh := float64(b.Dx()), float64(b.Dy())
+
+	switch {
+	case width <= 0:
+		width = int(math.Round(sw * float64(height) / sh))
+		crop = false
+	case height <= 0:
+		height = int(math.Round(sh * float64(width) / sw))
+		crop = false
+	}
+
+	w, h := float64(width), float64(height)
+	src := b
+	var scale float64
+	if crop {
+		scale = math.Min(math.Max(w/sw, h/sh), 1)
+		cw := int(math.Min(sw, math.Round(w/scale)))
+		ch := int(math.Min(sh, math.Round(h/scale)))
+		x := b.Min.X + (b.Dx()-cw)/2
+		y := b.Min.Y + (b.Dy()-ch)/2
+		src = image.Rect(x, y, x+cw, y+ch)
+	} else {
+		scale = math.Min(math.Min(w/sw, h/sh), 1)
+	}
+
+	dw := max1(int(math.Round(float64(src.Dx()) * scale)))
+	dh := max1(int(math.Round(float64(src.Dy()) * scale)))
+
+	// The source is converted to premultiplied RGBA, so transparent pixels
+	// do not tint their neighbours when averaged.
+	rgba := image.NewRGBA(image.Rect(0, 0, src.Dx(), src.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, src.Min, draw.Src)
+
+	return boxResize(rgba, dw, dh)
+}
+
+func max1(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// boxResize shrinks the image to the given size, making every pixel the
+// average of the area of the source it covers.
+func boxResize(src *image.RGBA, width, height int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw == width && sh == height {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, sh)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, sw)
+
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					sum[0] += uint64(p[0])
+					sum[1] += uint64(p[1])
+					sum[2] += uint64(p[2])
+					sum[3] += uint64(p[3])
+				}
+			}
+
+			n := uint64((y1 - y0) * (x1 - x0))
+			p := dst.Pix[y*dst.Stride+x*4:]
+			for i := range sum {
+				p[i] = uint8((sum[i] + n/2) / n)
+			}
+		}
+	}
+
+	return dst
+}
+
+// span returns the range of source pixels covered by the destination pixel
+// i, when n destination pixels cover size source pixels. The range is
+// never empty.
+func span(i, n, size int) (int, int) {
+	start := i * size / n
+	end := (i + 1) * size / n
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// encode encodes the thumbnail in the format of the options.
+func encode(img *image.RGBA, opts *Options) (*Thumbnail, error) {
+	thumb := &Thumbnail{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+
+	var (
+		buf bytes.Buffer
+		err error
+	)
+	switch opts.Format {
+	case PNG:
+		thumb.Type = "image/png"
+		err = png.Encode(&buf, img)
+	case 0, JPEG:
+		quality := opts.Quality
+		if quality <= 0 {
+			quality = DefaultQuality
+		}
+
+		bg := image.NewRGBA(img.Bounds())
+		draw.Draw(bg, bg.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(bg, bg.Bounds(), img, image.Point{}, draw.Over)
+
+		thumb.Type = "image/jpeg"
+		err = jpeg.Encode(&buf, bg, &jpeg.Options{Quality: quality})
+	default:
+		return nil, fmt.Errorf("thumbnail: invalid format: %d", opts.Format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	thumb.Data = buf.Bytes()
+	return thumb, nil
+}
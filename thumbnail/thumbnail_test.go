@@ -0,0 +1,205 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/stretchr/testify/assert"
+)
+
+func newImage(width, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerateSize(t *testing.T) {
+	data := encodePNG(newImage(400, 200, color.White))
+	cases := []struct {
+		opts          Options
+		width, height int
+	}{
+		{Options{Width: 100, Height: 100}, 100, 50},
+		{Options{Width: 100, Height: 100, Crop: true}, 100, 100},
+		{Options{Width: 100}, 100, 50},
+		{Options{Height: 100, Crop: true}, 200, 100},
+		{Options{Width: 800, Height: 800}, 400, 200},
+		{Options{Width: 800, Height: 100, Crop: true}, 400, 100},
+		{Options{Width: 1, Height: 1}, 1, 1},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		opts := c.opts
+		opts.Format = PNG
+		thumb, err := Generate(bytes.NewReader(data), &opts)
+		assert.Nil(err)
+		assert.Equal(thumb.Type, "image/png")
+		assert.Equal([]int{thumb.Width, thumb.Height}, []int{c.width, c.height}, "%+v", c.opts)
+
+		img, err := png.Decode(bytes.NewReader(thumb.Data))
+		assert.Nil(err)
+		assert.Equal(img.Bounds(), image.Rect(0, 0, c.width, c.height))
+	}
+}
+
+func TestGenerateCrop(t *testing.T) {
+	// The image has a red left third, a green middle third and a blue
+	// right third, so cropping its center leaves it green.
+	src := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			src.Set(x, y, []color.Color{
+				color.RGBA{255, 0, 0, 255},
+				color.RGBA{0, 255, 0, 255},
+				color.RGBA{0, 0, 255, 255},
+			}[x/100])
+		}
+	}
+
+	assert := assert.New(t)
+	thumb, err := Generate(bytes.NewReader(encodePNG(src)), &Options{Width: 10, Height: 10, Crop: true, Format: PNG})
+	assert.Nil(err)
+
+	img, err := png.Decode(bytes.NewReader(thumb.Data))
+	assert.Nil(err)
+	for _, p := range []image.Point{{0, 0}, {9, 9}, {5, 5}} {
+		assert.Equal(color.RGBAModel.Convert(img.At(p.X, p.Y)), color.RGBA{0, 255, 0, 255})
+	}
+
+	thumb, err = Generate(bytes.NewReader(encodePNG(src)), &Options{Width: 3, Format: PNG})
+	assert.Nil(err)
+	img, err = png.Decode(bytes.NewReader(thumb.Data))
+	assert.Nil(err)
+	assert.Equal(color.RGBAModel.Convert(img.At(0, 0)), color.RGBA{255, 0, 0, 255})
+	assert.Equal(color.RGBAModel.Convert(img.At(2, 0)), color.RGBA{0, 0, 255, 255})
+}
+
+func TestGenerateFormats(t *testing.T) {
+	var gifData bytes.Buffer
+	palette := color.Palette{color.Black, color.White}
+	assert := assert.New(t)
+	assert.Nil(gif.EncodeAll(&gifData, &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 40, 20), palette),
+			image.NewPaletted(image.Rect(0, 0, 40, 20), palette),
+		},
+		Delay: []int{10, 10},
+	}))
+
+	var jpegData bytes.Buffer
+	assert.Nil(jpeg.Encode(&jpegData, newImage(40, 20, color.Black), nil))
+
+	transparent := encodePNG(newImage(40, 20, color.Transparent))
+
+	for _, data := range [][]byte{gifData.Bytes(), jpegData.Bytes(), transparent} {
+		thumb, err := Generate(bytes.NewReader(data), &Options{Width: 20, Quality: 50})
+		assert.Nil(err)
+		assert.Equal(thumb.Type, "image/jpeg")
+		assert.Equal([]int{thumb.Width, thumb.Height}, []int{20, 10})
+
+		img, err := jpeg.Decode(bytes.NewReader(thumb.Data))
+		assert.Nil(err)
+		assert.Equal(img.Bounds(), image.Rect(0, 0, 20, 10))
+	}
+
+	// Transparent areas are rendered over white in JPEG thumbnails.
+	thumb, err := Generate(bytes.NewReader(transparent), &Options{Width: 20})
+	assert.Nil(err)
+	img, err := jpeg.Decode(bytes.NewReader(thumb.Data))
+	assert.Nil(err)
+	r, g, b, _ := img.At(10, 5).RGBA()
+	assert.True(r > 0xf000 && g > 0xf000 && b > 0xf000)
+
+	// and kept in PNG thumbnails.
+	thumb, err = Generate(bytes.NewReader(transparent), &Options{Width: 20, Format: PNG})
+	assert.Nil(err)
+	img, err = png.Decode(bytes.NewReader(thumb.Data))
+	assert.Nil(err)
+	_, _, _, a := img.At(10, 5).RGBA()
+	assert.Equal(a, uint32(0))
+}
+
+func TestGenerateErrors(t *testing.T) {
+	data := encodePNG(newImage(100, 100, color.White))
+	cases := []struct {
+		data []byte
+		opts *Options
+		err  error
+	}{
+		{data, nil, ErrNoSize},
+		{data, &Options{}, ErrNoSize},
+		{data, &Options{Width: 10, MaxBytes: 10}, ErrTooLarge},
+		{data, &Options{Width: 10, MaxPixels: 9999}, ErrTooLarge},
+		{[]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), &Options{Width: 10}, ErrUnsupportedFormat},
+		{[]byte("<svg></svg>"), &Options{Width: 10}, ErrUnsupportedFormat},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		_, err := Generate(bytes.NewReader(c.data), c.opts)
+		assert.Equal(err, c.err)
+	}
+
+	_, err := Generate(bytes.NewReader(data[:50]), &Options{Width: 10})
+	assert.NotNil(err)
+
+	_, err = Generate(bytes.NewReader(data), &Options{Width: 10, Format: Format(64)})
+	assert.NotNil(err)
+
+	thumb, err := Generate(bytes.NewReader(data), &Options{Width: 10, MaxBytes: int64(len(data)), MaxPixels: 10000})
+	assert.Nil(err)
+	assert.Equal(thumb.Width, 10)
+}
+
+func TestFetch(t *testing.T) {
+	data := encodePNG(newImage(200, 100, color.White))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			w.Write(data)
+		case "/chunked.png":
+			w.Write(data[:10])
+			w.(http.Flusher).Flush()
+			w.Write(data[10:])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	assert := assert.New(t)
+	thumb, err := Fetch(context.Background(), nil, srv.URL+"/image.png", &Options{Width: 50})
+	assert.Nil(err)
+	assert.Equal([]int{thumb.Width, thumb.Height}, []int{50, 25})
+
+	_, err = Fetch(context.Background(), nil, srv.URL+"/image.png", &Options{Width: 50, MaxBytes: 100})
+	assert.Equal(err, ErrTooLarge)
+
+	_, err = Fetch(context.Background(), nil, srv.URL+"/chunked.png", &Options{Width: 50, MaxBytes: 100})
+	assert.Equal(err, ErrTooLarge)
+
+	_, err = Fetch(context.Background(), nil, srv.URL+"/missing.png", &Options{Width: 50})
+	assert.Equal(err, &content.StatusError{Code: http.StatusNotFound})
+}
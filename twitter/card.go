@@ -2,17 +2,24 @@ package twitter
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/opengraph"
 )
 
-// Card contains all the data used to build a twitter card.
+// Card contains all the data used to build a twitter card. In JSON, its
+// embedded structs are encoded as objects named after them, which are
+// omitted if they are empty.
 type Card struct {
 	Type        CardType
 	Title       string
 	Description string
+	Domain      string
+	URL         string
+	Labels      []*Label
 	Site
 	Creator
 	Image
@@ -34,33 +41,48 @@ type Site struct {
 
 // Image is the representative image of the card.
 type Image struct {
-	URL string
-	Alt string
+	URL    string
+	Alt    string
+	Width  int
+	Height int
+	// Probed reports whether the size of the image was read from the image
+	// itself instead of its metatags.
+	Probed bool
+}
+
+// Label is an additional piece of data displayed on the card, such as the
+// reading time of an article or the price of a product.
+type Label struct {
+	Label string `json:"label"`
+	Data  string `json:"data"`
 }
 
 // App contains all the info about an "app" card with all the platforms
-// of the application.
+// of the application. In JSON, the platforms without info are omitted.
 type App struct {
 	IPhone     AppInfo
 	IPad       AppInfo
 	GooglePlay AppInfo
 	Country    string
+	// Others contains the info of the app for platforms other than iPhone,
+	// iPad and Google Play, keyed by the platform name used in the metatags.
+	Others map[string]*AppInfo
 }
 
 // AppInfo contains the information of an app for a specific platform.
 type AppInfo struct {
-	Name string
-	ID   string
-	URL  string
+	Name string `json:"name,omitempty"`
+	ID   string `json:"id,omitempty"`
+	URL  string `json:"url,omitempty"`
 }
 
 // Player contains all the data for a "player" card.
 type Player struct {
-	URL               string
-	Width             int
-	Height            int
-	Stream            string
-	StreamContentType string
+	URL               string `json:"url,omitempty"`
+	Width             int    `json:"width,omitempty"`
+	Height            int    `json:"height,omitempty"`
+	Stream            string `json:"stream,omitempty"`
+	StreamContentType string `json:"stream_content_type,omitempty"`
 }
 
 // CardType represents the kind of content the card will have.
@@ -98,6 +120,13 @@ const (
 	descriptionName             = "description"
 	imageName                   = "image"
 	imageAltName                = "image:alt"
+	imageSrcName                = "image:src"
+	imageWidthName              = "image:width"
+	imageHeightName             = "image:height"
+	domainName                  = "domain"
+	urlName                     = "url"
+	labelPrefix                 = "label"
+	dataPrefix                  = "data"
 	playerName                  = "player"
 	playerWidthName             = "player:width"
 	playerHeightName            = "player:height"
@@ -113,8 +142,27 @@ const (
 	ipadURLName                 = "app:url:ipad"
 	androidURLName              = "app:url:googleplay"
 	appCountryName              = "app:country"
+	appIDField                  = "id"
+	appNameField                = "name"
+	appURLField                 = "url"
 )
 
+// ExtractorName is the name of the twitter card Extractor.
+const ExtractorName = "twitter"
+
+// Extractor builds the twitter card of documents.
+type Extractor struct{}
+
+// Name returns the name of the extractor.
+func (Extractor) Name() string {
+	return ExtractorName
+}
+
+// Extract returns the twitter card of the document.
+func (Extractor) Extract(doc *content.Document) (interface{}, error) {
+	return NewCard(doc.Meta)
+}
+
 // NewCard returns a new twitter card object built with the metatags present
 // in the page.
 func NewCard(meta []*content.Meta) (*Card, error) {
@@ -122,6 +170,7 @@ func NewCard(meta []*content.Meta) (*Card, error) {
 		card   = new(Card)
 		player *Player
 		app    *App
+		labels = make(map[int]*Label)
 		err    error
 	)
 
@@ -161,6 +210,8 @@ func NewCard(meta []*content.Meta) (*Card, error) {
 				app.GooglePlay.URL = m.Value
 			case appCountryName:
 				app.Country = m.Value
+			default:
+				app.setOther(m.Name[len(appPrefix):], m.Value)
 			}
 		}
 
@@ -206,10 +257,30 @@ func NewCard(meta []*content.Meta) (*Card, error) {
 			card.Creator.User = m.Value
 		case creatorIDName:
 			card.Creator.ID = m.Value
-		case imageName:
+		case imageName, imageSrcName:
 			card.Image.URL = m.Value
 		case imageAltName:
 			card.Image.Alt = m.Value
+		case imageWidthName, imageHeightName:
+			n, err := strconv.Atoi(m.Value)
+			if err != nil {
+				return nil, err
+			}
+			if m.Name == imageHeightName {
+				card.Image.Height = n
+			} else {
+				card.Image.Width = n
+			}
+		case domainName:
+			card.Domain = m.Value
+		case urlName:
+			card.URL = m.Value
+		default:
+			if n, ok := labelIndex(m.Name, labelPrefix); ok {
+				labelAt(labels, n).Label = m.Value
+			} else if n, ok := labelIndex(m.Name, dataPrefix); ok {
+				labelAt(labels, n).Data = m.Value
+			}
 		}
 	}
 
@@ -221,9 +292,84 @@ func NewCard(meta []*content.Meta) (*Card, error) {
 		card.App = app
 	}
 
+	card.Labels = sortedLabels(labels)
 	return card, nil
 }
 
+// setOther sets the given field of the app for a platform that is not one of
+// the three documented by twitter. The name has the form "field:platform".
+func (a *App) setOther(name, value string) {
+	parts := strings.SplitN(name, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return
+	}
+
+	field, platform := parts[0], parts[1]
+	if field != appIDField && field != appNameField && field != appURLField {
+		return
+	}
+
+	if a.Others == nil {
+		a.Others = make(map[string]*AppInfo)
+	}
+
+	info, ok := a.Others[platform]
+	if !ok {
+		info = new(AppInfo)
+		a.Others[platform] = info
+	}
+
+	switch field {
+	case appIDField:
+		info.ID = value
+	case appNameField:
+		info.Name = value
+	case appURLField:
+		info.URL = value
+	}
+}
+
+// labelIndex returns the number N of a "labelN" or "dataN" metatag name.
+func labelIndex(name, prefix string) (int, bool) {
+	if !strings.HasPrefix(name, prefix) {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(name[len(prefix):])
+	if err != nil || n < 1 {
+		return 0, false
+	}
+
+	return n, true
+}
+
+func labelAt(labels map[int]*Label, n int) *Label {
+	l, ok := labels[n]
+	if !ok {
+		l = new(Label)
+		labels[n] = l
+	}
+	return l
+}
+
+func sortedLabels(labels map[int]*Label) []*Label {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	idx := make([]int, 0, len(labels))
+	for n := range labels {
+		idx = append(idx, n)
+	}
+	sort.Ints(idx)
+
+	result := make([]*Label, len(idx))
+	for i, n := range idx {
+		result[i] = labels[n]
+	}
+	return result
+}
+
 func filterTwitterMeta(meta []*content.Meta) (CardType, []*content.Meta, error) {
 	var (
 		typ         CardType
@@ -232,16 +378,21 @@ func filterTwitterMeta(meta []*content.Meta) (CardType, []*content.Meta, error)
 	)
 
 	for _, m := range meta {
-		if strings.HasPrefix(m.Name, twitterPrefix) {
-			m.Name = m.Name[len(twitterPrefix):]
-			if m.Name == cardName {
-				typ, err = cardType(m.Value)
-				if err != nil {
-					return typ, nil, err
-				}
-			} else {
-				twittermeta = append(twittermeta, m)
+		if !m.IsNameOrProperty() || !strings.HasPrefix(m.Name, twitterPrefix) {
+			continue
+		}
+
+		// The meta is copied so the name without prefix does not leak to
+		// other consumers of the same metas.
+		tm := *m
+		tm.Name = m.Name[len(twitterPrefix):]
+		if tm.Name == cardName {
+			typ, err = cardType(tm.Value)
+			if err != nil {
+				return typ, nil, err
 			}
+		} else {
+			twittermeta = append(twittermeta, &tm)
 		}
 	}
 
@@ -270,3 +421,97 @@ func cardType(t string) (typ CardType, err error) {
 	}
 	return
 }
+
+// String returns the name of the card type used in the twitter:card
+// metatag, or an empty string if the type is unknown.
+func (t CardType) String() string {
+	switch t {
+	case SummaryCard:
+		return summary
+	case SummaryBigPictureCard:
+		return summaryLargePicture
+	case AppCard:
+		return app
+	case PlayerCard:
+		return player
+	}
+	return ""
+}
+
+// MarshalText encodes the card type as its name in the twitter:card
+// metatag.
+func (t CardType) MarshalText() ([]byte, error) {
+	if t == 0 {
+		return nil, nil
+	}
+
+	s := t.String()
+	if s == "" {
+		return nil, fmt.Errorf("invalid card type: %d", t)
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText decodes a card type from its name in the twitter:card
+// metatag. An empty name is decoded as no card type.
+func (t *CardType) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*t = 0
+		return nil
+	}
+
+	typ, err := cardType(string(text))
+	if err != nil {
+		return err
+	}
+
+	*t = typ
+	return nil
+}
+
+// Effective returns the card as twitter would render it, applying the
+// documented fallbacks to the OpenGraph object of the page for the values
+// that are missing in the twitter metatags: og:title, og:description,
+// og:image and og:url. Twitter does not derive the card type from og:type,
+// so if the page does not declare a card type but the resulting card has a
+// title, it will be rendered as a summary card, as twitter does.
+// Neither the card nor the object given are modified.
+func Effective(card *Card, obj *opengraph.Object) *Card {
+	c := new(Card)
+	if card != nil {
+		*c = *card
+	}
+
+	if obj == nil {
+		return c
+	}
+
+	if c.Title == "" {
+		c.Title = obj.Title
+	}
+
+	if c.Description == "" {
+		c.Description = obj.Description
+	}
+
+	if c.URL == "" {
+		c.URL = obj.URL
+	}
+
+	if c.Image.URL == "" && len(obj.Images) > 0 {
+		img := obj.Images[0]
+		c.Image = Image{
+			URL:    img.URL,
+			Alt:    c.Image.Alt,
+			Width:  img.Width,
+			Height: img.Height,
+			Probed: img.Probed,
+		}
+	}
+
+	if c.Type == 0 && c.Title != "" {
+		c.Type = SummaryCard
+	}
+
+	return c
+}
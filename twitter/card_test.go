@@ -2,9 +2,11 @@ package twitter
 
 import (
 	"fmt"
+	"strconv"
 	"testing"
 
 	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/opengraph"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,16 +35,42 @@ func TestNewCard(t *testing.T) {
 			Creator:     Creator{"4321", "creator1"},
 			Title:       "title",
 			Description: "description",
-			Image:       Image{"image1", "image alt"},
+			Image:       Image{URL: "image1", Alt: "image alt"},
 		}},
+		{makeMeta("twitter:card", "summary_large_image",
+			"twitter:domain", "foo.bar",
+			"twitter:url", "http://foo.bar/baz",
+			"twitter:image:src", "image1",
+			"twitter:image:width", "600",
+			"twitter:image:height", "300",
+			"twitter:label2", "Price",
+			"twitter:data2", "$3",
+			"twitter:label1", "Reading time",
+			"twitter:data1", "5 min read",
+		), nil, &Card{
+			Type:   SummaryBigPictureCard,
+			Domain: "foo.bar",
+			URL:    "http://foo.bar/baz",
+			Image:  Image{URL: "image1", Width: 600, Height: 300},
+			Labels: []*Label{
+				{"Reading time", "5 min read"},
+				{"Price", "$3"},
+			},
+		}},
+		{makeMeta("twitter:card", "summary",
+			"twitter:image", "image1",
+			"twitter:image:width", "wide",
+		), &strconv.NumError{Func: "Atoi", Num: "wide", Err: strconv.ErrSyntax}, nil},
 		{makeMeta("twitter:card", "player",
 			"twitter:player", "player",
 			"twitter:player:width", "5",
 			"twitter:player:height", "10",
 			"twitter:player:stream", "stream",
 			"twitter:player:stream:content_type", "content_type",
+			"twitter:creator", "creator1",
 		), nil, &Card{
-			Type: PlayerCard,
+			Type:    PlayerCard,
+			Creator: Creator{User: "creator1"},
 			Player: &Player{
 				URL:               "player",
 				Width:             5,
@@ -62,6 +90,9 @@ func TestNewCard(t *testing.T) {
 			"twitter:app:name:iphone", "nameiphone",
 			"twitter:app:name:googleplay", "namegp",
 			"twitter:app:country", "US",
+			"twitter:app:id:windows", "idwin",
+			"twitter:app:name:windows", "namewin",
+			"twitter:app:foo:windows", "ignored",
 		), nil, &Card{
 			Type: AppCard,
 			App: &App{
@@ -81,6 +112,9 @@ func TestNewCard(t *testing.T) {
 					Name: "nameipad",
 					ID:   "idipad",
 				},
+				Others: map[string]*AppInfo{
+					"windows": {Name: "namewin", ID: "idwin"},
+				},
 			},
 		}},
 	}
@@ -95,6 +129,20 @@ func TestNewCard(t *testing.T) {
 	}
 }
 
+func TestNewCardMetaNotModified(t *testing.T) {
+	assert := assert.New(t)
+	meta := []*content.Meta{
+		{Name: "twitter:card", Value: "summary", Attr: content.AttrProperty},
+		{Name: "twitter:title", Value: "title", Attr: content.AttrName},
+		{Name: "twitter:description", Value: "desc", Attr: content.AttrItemprop},
+	}
+
+	card, err := NewCard(meta)
+	assert.Nil(err)
+	assert.Equal(card, &Card{Type: SummaryCard, Title: "title"})
+	assert.Equal(meta[1].Name, "twitter:title")
+}
+
 func makeMeta(s ...string) []*content.Meta {
 	if len(s)%2 != 0 {
 		panic("i need k-v pairs")
@@ -110,3 +158,93 @@ func makeMeta(s ...string) []*content.Meta {
 
 	return meta
 }
+
+func TestEffective(t *testing.T) {
+	obj := &opengraph.Object{
+		Title:       "og title",
+		Description: "og description",
+		URL:         "http://foo.bar",
+		Images: []*opengraph.Image{
+			{
+				MediaProperties: opengraph.MediaProperties{URL: "image1"},
+				Size:            opengraph.Size{Width: 200, Height: 100},
+			},
+			{
+				MediaProperties: opengraph.MediaProperties{URL: "image2"},
+			},
+		},
+	}
+
+	cases := []struct {
+		card     *Card
+		obj      *opengraph.Object
+		expected *Card
+	}{
+		{nil, nil, &Card{}},
+		{&Card{Type: AppCard, Title: "title"}, nil, &Card{Type: AppCard, Title: "title"}},
+		{nil, &opengraph.Object{Description: "desc"}, &Card{Description: "desc"}},
+		{nil, obj, &Card{
+			Type:        SummaryCard,
+			Title:       "og title",
+			Description: "og description",
+			URL:         "http://foo.bar",
+			Image:       Image{URL: "image1", Width: 200, Height: 100},
+		}},
+		{&Card{
+			Type:        SummaryBigPictureCard,
+			Title:       "title",
+			Description: "description",
+			URL:         "http://foo.bar/baz",
+			Image:       Image{URL: "image", Alt: "alt"},
+		}, obj, &Card{
+			Type:        SummaryBigPictureCard,
+			Title:       "title",
+			Description: "description",
+			URL:         "http://foo.bar/baz",
+			Image:       Image{URL: "image", Alt: "alt"},
+		}},
+		{&Card{Type: SummaryBigPictureCard, Image: Image{Alt: "alt"}}, obj, &Card{
+			Type:        SummaryBigPictureCard,
+			Title:       "og title",
+			Description: "og description",
+			URL:         "http://foo.bar",
+			Image:       Image{URL: "image1", Alt: "alt", Width: 200, Height: 100},
+		}},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		var orig Card
+		if c.card != nil {
+			orig = *c.card
+		}
+
+		assert.Equal(Effective(c.card, c.obj), c.expected)
+		if c.card != nil {
+			assert.Equal(*c.card, orig)
+		}
+	}
+}
+
+func TestCardTypeString(t *testing.T) {
+	cases := []struct {
+		typ      CardType
+		expected string
+	}{
+		{SummaryCard, "summary"},
+		{SummaryBigPictureCard, "summary_large_image"},
+		{AppCard, "app"},
+		{PlayerCard, "player"},
+		{0, ""},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		assert.Equal(c.typ.String(), c.expected)
+		if c.expected != "" {
+			typ, err := cardType(c.expected)
+			assert.Nil(err)
+			assert.Equal(typ, c.typ)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package twitter
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"golang.org/x/net/html"
+)
+
+// MarshalHTML encodes the card as the <meta> tags that declare it, one per
+// line. Empty values are omitted, as well as the values NewCard ignores,
+// such as the player of a card that is not a player card. Labels are
+// numbered by their position. NUL characters cannot be represented in
+// HTML, and are read back as U+FFFD.
+func (c *Card) MarshalHTML() ([]byte, error) {
+	var w metaWriter
+	if c.Type != 0 {
+		if c.Type.String() == "" {
+			return nil, fmt.Errorf("invalid card type: %d", c.Type)
+		}
+		w.write(cardName, c.Type.String())
+	}
+
+	w.write(siteName, c.Site.User)
+	w.write(siteIDName, c.Site.ID)
+	w.write(creatorName, c.Creator.User)
+	w.write(creatorIDName, c.Creator.ID)
+	w.write(titleName, c.Title)
+	w.write(descriptionName, c.Description)
+	w.write(domainName, c.Domain)
+	w.write(urlName, c.URL)
+	w.write(imageName, c.Image.URL)
+	w.write(imageAltName, c.Image.Alt)
+	w.writeInt(imageWidthName, c.Image.Width)
+	w.writeInt(imageHeightName, c.Image.Height)
+
+	for i, l := range c.Labels {
+		if l == nil {
+			continue
+		}
+		n := strconv.Itoa(i + 1)
+		w.write(labelPrefix+n, l.Label)
+		w.write(dataPrefix+n, l.Data)
+	}
+
+	if p := c.Player; p != nil && c.Type == PlayerCard {
+		w.write(playerName, p.URL)
+		w.writeInt(playerWidthName, p.Width)
+		w.writeInt(playerHeightName, p.Height)
+		w.write(playerStreamName, p.Stream)
+		w.write(playerStreamContentTypeName, p.StreamContentType)
+	}
+
+	if a := c.App; a != nil && c.Type == AppCard {
+		w.write(iphoneNameName, a.IPhone.Name)
+		w.write(iphoneIDName, a.IPhone.ID)
+		w.write(iphoneURLName, a.IPhone.URL)
+		w.write(ipadNameName, a.IPad.Name)
+		w.write(ipadIDName, a.IPad.ID)
+		w.write(ipadURLName, a.IPad.URL)
+		w.write(androidNameName, a.GooglePlay.Name)
+		w.write(androidIDName, a.GooglePlay.ID)
+		w.write(androidURLName, a.GooglePlay.URL)
+		w.write(appCountryName, a.Country)
+
+		platforms := make([]string, 0, len(a.Others))
+		for p := range a.Others {
+			switch p {
+			case "", "iphone", "ipad", "googleplay":
+				return nil, fmt.Errorf("invalid app platform: %q", p)
+			}
+			platforms = append(platforms, p)
+		}
+		sort.Strings(platforms)
+
+		for _, p := range platforms {
+			info := a.Others[p]
+			if info == nil {
+				continue
+			}
+			w.write(appPrefix+appNameField+":"+p, info.Name)
+			w.write(appPrefix+appIDField+":"+p, info.ID)
+			w.write(appPrefix+appURLField+":"+p, info.URL)
+		}
+	}
+
+	return w.Bytes(), nil
+}
+
+// metaWriter writes twitter <meta> tags.
+type metaWriter struct {
+	bytes.Buffer
+}
+
+// write writes the <meta> tag of the given property without the twitter:
+// prefix, unless the value is empty.
+func (w *metaWriter) write(name, value string) {
+	if value == "" {
+		return
+	}
+
+	w.WriteString(`<meta name="`)
+	w.WriteString(html.EscapeString(twitterPrefix + name))
+	w.WriteString(`" content="`)
+	w.WriteString(html.EscapeString(value))
+	w.WriteString("\">\n")
+}
+
+func (w *metaWriter) writeInt(name string, n int) {
+	if n != 0 {
+		w.write(name, strconv.Itoa(n))
+	}
+}
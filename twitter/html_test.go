@@ -0,0 +1,141 @@
+package twitter
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalHTML(t *testing.T) {
+	card := &Card{
+		Type:   SummaryCard,
+		Title:  `"Foo" & <Bar>`,
+		Site:   Site{User: "@foo"},
+		Labels: []*Label{{Label: "Price", Data: "10 €"}},
+		Player: &Player{URL: "https://foo.bar/player"},
+	}
+
+	assert := assert.New(t)
+	data, err := card.MarshalHTML()
+	assert.Nil(err)
+	assert.Equal(string(data), `<meta name="twitter:card" content="summary">
+<meta name="twitter:site" content="@foo">
+<meta name="twitter:title" content="&#34;Foo&#34; &amp; &lt;Bar&gt;">
+<meta name="twitter:label1" content="Price">
+<meta name="twitter:data1" content="10 €">
+`)
+
+	for _, c := range []*Card{
+		{Type: CardType(64)},
+		{Type: AppCard, App: &App{Others: map[string]*AppInfo{"iphone": {ID: "1"}}}},
+	} {
+		_, err := c.MarshalHTML()
+		assert.NotNil(err)
+	}
+}
+
+// randomCard is a Card with random values that can be encoded as HTML.
+type randomCard struct {
+	*Card
+}
+
+func (randomCard) Generate(r *rand.Rand, size int) reflect.Value {
+	types := []CardType{0, SummaryCard, SummaryBigPictureCard, AppCard, PlayerCard}
+	card := &Card{
+		Type:        types[r.Intn(len(types))],
+		Title:       randomString(r),
+		Description: randomString(r),
+		Domain:      randomString(r),
+		URL:         randomString(r),
+		Site:        Site{ID: randomString(r), User: randomString(r)},
+		Creator:     Creator{ID: randomString(r), User: randomString(r)},
+		Image: Image{
+			URL:    randomString(r),
+			Alt:    randomString(r),
+			Width:  r.Intn(2000),
+			Height: r.Intn(2000),
+		},
+	}
+
+	for i := r.Intn(4); i > 0; i-- {
+		l := &Label{Label: randomString(r), Data: nonEmptyString(r)}
+		card.Labels = append(card.Labels, l)
+	}
+
+	switch card.Type {
+	case PlayerCard:
+		card.Player = &Player{
+			URL:               nonEmptyString(r),
+			Width:             r.Intn(2000),
+			Height:            r.Intn(2000),
+			Stream:            randomString(r),
+			StreamContentType: randomString(r),
+		}
+	case AppCard:
+		card.App = &App{
+			IPhone:     randomAppInfo(r),
+			IPad:       randomAppInfo(r),
+			GooglePlay: randomAppInfo(r),
+			Country:    nonEmptyString(r),
+		}
+		for i := r.Intn(3); i > 0; i-- {
+			if card.App.Others == nil {
+				card.App.Others = make(map[string]*AppInfo)
+			}
+			info := randomAppInfo(r)
+			info.ID = nonEmptyString(r)
+			card.App.Others["platform"+nonEmptyString(r)] = &info
+		}
+	}
+
+	return reflect.ValueOf(randomCard{card})
+}
+
+func TestMarshalHTMLRoundTrip(t *testing.T) {
+	roundTrip := func(c randomCard) bool {
+		data, err := c.MarshalHTML()
+		if err != nil {
+			return false
+		}
+
+		meta, err := content.Parse(bytes.NewReader(data), content.ScopeHead)
+		if err != nil {
+			return false
+		}
+
+		card, err := NewCard(meta)
+		return err == nil && reflect.DeepEqual(card, c.Card)
+	}
+
+	assert.Nil(t, quick.Check(roundTrip, &quick.Config{MaxCount: 500}))
+}
+
+// alphabet contains the characters of random strings, including the ones
+// that need to be escaped in HTML and non-ASCII ones.
+var alphabet = []rune("ab Z09&<>\"'=;#\r\n\t/:.é €\U0001F600")
+
+// randomString returns a random string, which is empty a fourth of the
+// times.
+func randomString(r *rand.Rand) string {
+	if r.Intn(4) == 0 {
+		return ""
+	}
+	return nonEmptyString(r)
+}
+
+func nonEmptyString(r *rand.Rand) string {
+	s := make([]rune, 1+r.Intn(20))
+	for i := range s {
+		s[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(s)
+}
+
+func randomAppInfo(r *rand.Rand) AppInfo {
+	return AppInfo{Name: randomString(r), ID: randomString(r), URL: randomString(r)}
+}
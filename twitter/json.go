@@ -0,0 +1,144 @@
+package twitter
+
+import "encoding/json"
+
+// cardJSON is the JSON representation of a Card.
+type cardJSON struct {
+	Type        CardType     `json:"type,omitempty"`
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Domain      string       `json:"domain,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Labels      []*Label     `json:"labels,omitempty"`
+	Site        *accountJSON `json:"site,omitempty"`
+	Creator     *accountJSON `json:"creator,omitempty"`
+	Image       *imageJSON   `json:"image,omitempty"`
+	Player      *Player      `json:"player,omitempty"`
+	App         *App         `json:"app,omitempty"`
+}
+
+// imageJSON is the JSON representation of an Image.
+type imageJSON struct {
+	URL    string `json:"url,omitempty"`
+	Alt    string `json:"alt,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Probed bool   `json:"probed,omitempty"`
+}
+
+// accountJSON is the JSON representation of a Site or a Creator.
+type accountJSON struct {
+	ID   string `json:"id,omitempty"`
+	User string `json:"user,omitempty"`
+}
+
+// MarshalJSON encodes the card as JSON.
+func (c Card) MarshalJSON() ([]byte, error) {
+	j := cardJSON{
+		Type:        c.Type,
+		Title:       c.Title,
+		Description: c.Description,
+		Domain:      c.Domain,
+		URL:         c.URL,
+		Labels:      c.Labels,
+		Player:      c.Player,
+		App:         c.App,
+	}
+
+	if c.Site != (Site{}) {
+		j.Site = &accountJSON{c.Site.ID, c.Site.User}
+	}
+
+	if c.Creator != (Creator{}) {
+		j.Creator = &accountJSON{c.Creator.ID, c.Creator.User}
+	}
+
+	if c.Image != (Image{}) {
+		j.Image = (*imageJSON)(&c.Image)
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes a card encoded as JSON.
+func (c *Card) UnmarshalJSON(data []byte) error {
+	var j cardJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*c = Card{
+		Type:        j.Type,
+		Title:       j.Title,
+		Description: j.Description,
+		Domain:      j.Domain,
+		URL:         j.URL,
+		Labels:      j.Labels,
+		Player:      j.Player,
+		App:         j.App,
+	}
+
+	if j.Site != nil {
+		c.Site = Site{j.Site.ID, j.Site.User}
+	}
+
+	if j.Creator != nil {
+		c.Creator = Creator{j.Creator.ID, j.Creator.User}
+	}
+
+	if j.Image != nil {
+		c.Image = Image(*j.Image)
+	}
+
+	return nil
+}
+
+// appJSON is the JSON representation of an App.
+type appJSON struct {
+	IPhone     *AppInfo            `json:"iphone,omitempty"`
+	IPad       *AppInfo            `json:"ipad,omitempty"`
+	GooglePlay *AppInfo            `json:"googleplay,omitempty"`
+	Country    string              `json:"country,omitempty"`
+	Others     map[string]*AppInfo `json:"others,omitempty"`
+}
+
+// MarshalJSON encodes the app as JSON.
+func (a App) MarshalJSON() ([]byte, error) {
+	j := appJSON{Country: a.Country, Others: a.Others}
+	if a.IPhone != (AppInfo{}) {
+		j.IPhone = &a.IPhone
+	}
+
+	if a.IPad != (AppInfo{}) {
+		j.IPad = &a.IPad
+	}
+
+	if a.GooglePlay != (AppInfo{}) {
+		j.GooglePlay = &a.GooglePlay
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes an app encoded as JSON.
+func (a *App) UnmarshalJSON(data []byte) error {
+	var j appJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*a = App{Country: j.Country, Others: j.Others}
+	if j.IPhone != nil {
+		a.IPhone = *j.IPhone
+	}
+
+	if j.IPad != nil {
+		a.IPad = *j.IPad
+	}
+
+	if j.GooglePlay != nil {
+		a.GooglePlay = *j.GooglePlay
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package twitter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardJSON(t *testing.T) {
+	cases := []struct {
+		card *Card
+		json string
+	}{
+		{&Card{}, `{}`},
+		{&Card{Type: SummaryCard, Title: "Foo"}, `{"type": "summary", "title": "Foo"}`},
+		{
+			&Card{
+				Type:    AppCard,
+				URL:     "http://foo.bar",
+				Site:    Site{ID: "1", User: "@site"},
+				Creator: Creator{User: "@creator"},
+				Image:   Image{URL: "http://foo.bar/a.png", Alt: "alt"},
+				App:     &App{GooglePlay: AppInfo{ID: "bar.foo"}, Country: "ES"},
+			},
+			`{
+				"type": "app",
+				"url": "http://foo.bar",
+				"site": {"id": "1", "user": "@site"},
+				"creator": {"user": "@creator"},
+				"image": {"url": "http://foo.bar/a.png", "alt": "alt"},
+				"app": {"googleplay": {"id": "bar.foo"}, "country": "ES"}
+			}`,
+		},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		data, err := json.Marshal(c.card)
+		assert.Nil(err)
+		assert.JSONEq(string(data), c.json)
+
+		var card Card
+		assert.Nil(json.Unmarshal(data, &card))
+		assert.Equal(&card, c.card)
+	}
+}
+
+func TestCardTypeJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	var card Card
+	err := json.Unmarshal([]byte(`{"type": "gallery"}`), &card)
+	assert.NotNil(err)
+
+	_, err = json.Marshal(&Card{Type: CardType(64)})
+	assert.NotNil(err)
+}
@@ -0,0 +1,409 @@
+// Package validate checks the OpenGraph and twitter card metatags of a
+// webpage against the requirements of the platforms that render them, in
+// the manner of their debuggers.
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mvader/pagecard/content"
+)
+
+// Severity is how serious an issue is.
+type Severity byte
+
+const (
+	// Error is an issue that prevents the preview of the webpage from
+	// being rendered, or makes it render wrongly.
+	Error Severity = 1 << iota
+	// Warning is an issue that makes the preview worse than it could be.
+	Warning
+	// Notice is a recommendation to improve the preview.
+	Notice
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Notice:
+		return "notice"
+	}
+	return ""
+}
+
+// MarshalText encodes the severity as its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity from its name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, sev := range []Severity{Error, Warning, Notice} {
+		if sev.String() == string(text) {
+			*s = sev
+			return nil
+		}
+	}
+	return fmt.Errorf("validate: invalid severity: %s", text)
+}
+
+// Rule IDs of the issues reported.
+const (
+	// RuleMissingRequired is reported for every required OpenGraph
+	// property missing.
+	RuleMissingRequired = "og-missing-required"
+	// RuleUnknownType is reported when og:type is not one of the types
+	// defined by OpenGraph or a namespaced custom type.
+	RuleUnknownType = "og-unknown-type"
+	// RuleOrphanProperty is reported for a structured property, such as
+	// og:image:width, that is not preceded by its root property.
+	RuleOrphanProperty = "og-orphan-property"
+	// RuleImageTooSmall is reported when og:image is smaller than the
+	// minimum size of Facebook.
+	RuleImageTooSmall = "og-image-too-small"
+	// RuleImageSizeMissing is reported when the size of og:image is not
+	// declared, so platforms need to download it before rendering.
+	RuleImageSizeMissing = "og-image-size-missing"
+	// RuleInvalidURL is reported for properties that must be absolute
+	// http or https URLs and are not.
+	RuleInvalidURL = "invalid-url"
+	// RuleInvalidNumber is reported for sizes that are not positive
+	// integers.
+	RuleInvalidNumber = "invalid-number"
+	// RuleConflictingDuplicate is reported for properties that can only
+	// have one value and are declared more than once with different
+	// values.
+	RuleConflictingDuplicate = "conflicting-duplicate"
+	// RuleTwitterMissingCard is reported when twitter:card is missing.
+	RuleTwitterMissingCard = "twitter-missing-card"
+	// RuleTwitterInvalidCard is reported when twitter:card is not a known
+	// card type.
+	RuleTwitterInvalidCard = "twitter-invalid-card"
+	// RuleTwitterMissingRequired is reported for every property required
+	// by the card type missing, taking into account the OpenGraph
+	// fallbacks of twitter.
+	RuleTwitterMissingRequired = "twitter-missing-required"
+	// RuleTwitterImageTooSmall is reported when the image of the card is
+	// smaller than the minimum size of its card type.
+	RuleTwitterImageTooSmall = "twitter-image-too-small"
+	// RuleTwitterInsecurePlayer is reported when twitter:player is not
+	// served over https.
+	RuleTwitterInsecurePlayer = "twitter-insecure-player"
+	// RuleTwitterInvalidUsername is reported when twitter:site or
+	// twitter:creator are not @usernames.
+	RuleTwitterInvalidUsername = "twitter-invalid-username"
+)
+
+// Issue is a problem found in the metatags of a webpage.
+type Issue struct {
+	// Rule is the ID of the rule that found the issue.
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// Property is the name of the metatag with the issue.
+	Property string `json:"property"`
+	Message  string `json:"message"`
+}
+
+const (
+	// Facebook requires images of at least 200x200 pixels.
+	minOGImageWidth  = 200
+	minOGImageHeight = 200
+	// Summary cards require images of at least 144x144 pixels.
+	minSummaryImageWidth  = 144
+	minSummaryImageHeight = 144
+	// Summary cards with large images require images of at least 300x157
+	// pixels.
+	minLargeImageWidth  = 300
+	minLargeImageHeight = 157
+)
+
+var requiredOG = []string{"og:title", "og:type", "og:image", "og:url"}
+
+// singleValued are the properties that can only have one value.
+var singleValued = []string{
+	"og:title", "og:type", "og:url", "og:description", "og:site_name", "og:locale",
+	"twitter:card", "twitter:title", "twitter:description", "twitter:site",
+	"twitter:creator", "twitter:image", "twitter:player",
+}
+
+// urlProperties are the properties whose values must be absolute URLs.
+var urlProperties = map[string]bool{
+	"og:url":                true,
+	"og:image":              true,
+	"og:image:url":          true,
+	"og:image:secure_url":   true,
+	"og:video":              true,
+	"og:video:url":          true,
+	"og:video:secure_url":   true,
+	"og:audio":              true,
+	"og:audio:url":          true,
+	"og:audio:secure_url":   true,
+	"twitter:url":           true,
+	"twitter:image":         true,
+	"twitter:image:src":     true,
+	"twitter:player":        true,
+	"twitter:player:stream": true,
+}
+
+var ogTypes = map[string]bool{
+	"website":             true,
+	"article":             true,
+	"book":                true,
+	"profile":             true,
+	"music.song":          true,
+	"music.album":         true,
+	"music.playlist":      true,
+	"music.radio_station": true,
+	"video.movie":         true,
+	"video.episode":       true,
+	"video.tv_show":       true,
+	"video.other":         true,
+}
+
+// Check returns the issues found in the given metatags, in the order they
+// were checked.
+func Check(meta []*content.Meta) []*Issue {
+	c := &checker{values: make(map[string][]string)}
+	for _, m := range meta {
+		if !m.IsNameOrProperty() {
+			continue
+		}
+
+		if strings.HasPrefix(m.Name, "og:") || strings.HasPrefix(m.Name, "twitter:") {
+			c.meta = append(c.meta, m)
+			c.values[m.Name] = append(c.values[m.Name], m.Value)
+		}
+	}
+
+	c.checkOpenGraph()
+	c.checkValues()
+	c.checkDuplicates()
+	c.checkTwitter()
+	return c.issues
+}
+
+type checker struct {
+	meta   []*content.Meta
+	values map[string][]string
+	issues []*Issue
+}
+
+func (c *checker) report(rule string, severity Severity, property, format string, args ...interface{}) {
+	c.issues = append(c.issues, &Issue{
+		Rule:     rule,
+		Severity: severity,
+		Property: property,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// value returns the last value of the property, if any, which is the one
+// the opengraph and twitter packages keep.
+func (c *checker) value(name string) string {
+	if v := c.values[name]; len(v) > 0 {
+		return v[len(v)-1]
+	}
+	return ""
+}
+
+func (c *checker) checkOpenGraph() {
+	for _, name := range requiredOG {
+		if c.value(name) == "" {
+			c.report(RuleMissingRequired, Error, name, "required property %s is missing", name)
+		}
+	}
+
+	if t := c.value("og:type"); t != "" && !ogTypes[t] && !strings.Contains(t, ":") {
+		c.report(RuleUnknownType, Warning, "og:type", "%q is not a known type", t)
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range c.meta {
+		for _, root := range []string{"og:image", "og:video", "og:audio"} {
+			if m.Name == root {
+				seen[root] = true
+			} else if strings.HasPrefix(m.Name, root+":") && !seen[root] {
+				c.report(RuleOrphanProperty, Error, m.Name, "%s must be declared after %s", m.Name, root)
+			}
+		}
+	}
+
+	if c.value("og:image") == "" {
+		return
+	}
+
+	width, height, ok := c.imageSize("og:image")
+	switch {
+	case !ok:
+		c.report(RuleImageSizeMissing, Notice, "og:image",
+			"declare og:image:width and og:image:height so the image can be rendered before it is downloaded")
+	case width < minOGImageWidth || height < minOGImageHeight:
+		c.report(RuleImageTooSmall, Warning, "og:image",
+			"image is %dx%d, but it must be at least %dx%d", width, height, minOGImageWidth, minOGImageHeight)
+	}
+}
+
+// imageSize returns the size declared for the first image of the given
+// property, if both its width and height are declared.
+func (c *checker) imageSize(root string) (width, height int, ok bool) {
+	var found, hasWidth, hasHeight bool
+	for _, m := range c.meta {
+		if m.Name == root {
+			if found {
+				break
+			}
+			found = true
+			continue
+		}
+
+		if !found {
+			continue
+		}
+
+		switch m.Name {
+		case root + ":width":
+			width, hasWidth = parseSize(m.Value)
+		case root + ":height":
+			height, hasHeight = parseSize(m.Value)
+		}
+	}
+
+	return width, height, hasWidth && hasHeight
+}
+
+func (c *checker) checkValues() {
+	for _, m := range c.meta {
+		if urlProperties[m.Name] && m.Value != "" && !isAbsoluteURL(m.Value) {
+			c.report(RuleInvalidURL, Error, m.Name, "%q is not an absolute http or https URL", m.Value)
+		}
+
+		if strings.HasSuffix(m.Name, ":width") || strings.HasSuffix(m.Name, ":height") {
+			if _, ok := parseSize(m.Value); !ok {
+				c.report(RuleInvalidNumber, Error, m.Name, "%q is not a positive integer", m.Value)
+			}
+		}
+	}
+}
+
+func (c *checker) checkDuplicates() {
+	for _, name := range singleValued {
+		values := c.values[name]
+		for _, v := range values {
+			if v != values[0] {
+				c.report(RuleConflictingDuplicate, Warning, name,
+					"%s is declared %d times with different values, only the last one is used", name, len(values))
+				break
+			}
+		}
+	}
+}
+
+func (c *checker) checkTwitter() {
+	card := c.value("twitter:card")
+	if card == "" {
+		c.report(RuleTwitterMissingCard, Warning, "twitter:card",
+			"twitter:card is missing, so the page will be rendered as a summary card if it has a title")
+		card = "summary"
+	}
+
+	var required []string
+	switch card {
+	case "summary":
+		required = []string{"twitter:title"}
+	case "summary_large_image":
+		required = []string{"twitter:title", "twitter:image"}
+	case "player":
+		required = []string{
+			"twitter:title", "twitter:site", "twitter:image",
+			"twitter:player", "twitter:player:width", "twitter:player:height",
+		}
+	case "app":
+		required = []string{"twitter:site"}
+		if c.value("twitter:app:id:iphone") == "" &&
+			c.value("twitter:app:id:ipad") == "" &&
+			c.value("twitter:app:id:googleplay") == "" {
+			c.report(RuleTwitterMissingRequired, Error, "twitter:app:id:iphone",
+				"app cards require the ID of the app in at least one of iPhone, iPad or Google Play")
+		}
+	default:
+		c.report(RuleTwitterInvalidCard, Error, "twitter:card", "%q is not a known card type", card)
+		return
+	}
+
+	for _, name := range required {
+		if c.twitterValue(name) == "" {
+			c.report(RuleTwitterMissingRequired, Error, name, "%s cards require %s", card, name)
+		}
+	}
+
+	if p := c.value("twitter:player"); card == "player" && p != "" && !strings.HasPrefix(p, "https://") {
+		c.report(RuleTwitterInsecurePlayer, Error, "twitter:player", "the player must be served over https")
+	}
+
+	for _, name := range []string{"twitter:site", "twitter:creator"} {
+		if v := c.value(name); v != "" && !strings.HasPrefix(v, "@") {
+			c.report(RuleTwitterInvalidUsername, Warning, name, "%q is not a @username", v)
+		}
+	}
+
+	c.checkTwitterImage(card)
+}
+
+// twitterValue returns the value of the twitter property, or the one of
+// the OpenGraph property twitter falls back to.
+func (c *checker) twitterValue(name string) string {
+	if v := c.value(name); v != "" {
+		return v
+	}
+
+	switch name {
+	case "twitter:image":
+		if v := c.value("twitter:image:src"); v != "" {
+			return v
+		}
+	case "twitter:title", "twitter:description", "twitter:url":
+	default:
+		return ""
+	}
+
+	return c.value("og:" + name[len("twitter:"):])
+}
+
+func (c *checker) checkTwitterImage(card string) {
+	var minWidth, minHeight int
+	switch card {
+	case "summary":
+		minWidth, minHeight = minSummaryImageWidth, minSummaryImageHeight
+	case "summary_large_image":
+		minWidth, minHeight = minLargeImageWidth, minLargeImageHeight
+	default:
+		return
+	}
+
+	width, height, ok := c.imageSize("twitter:image")
+	if !ok && c.value("twitter:image") == "" && c.value("twitter:image:src") == "" {
+		width, height, ok = c.imageSize("og:image")
+	}
+
+	if ok && (width < minWidth || height < minHeight) {
+		c.report(RuleTwitterImageTooSmall, Warning, "twitter:image",
+			"image is %dx%d, but %s cards require at least %dx%d", width, height, card, minWidth, minHeight)
+	}
+}
+
+func parseSize(s string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	return n, err == nil && n > 0
+}
+
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
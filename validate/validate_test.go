@@ -0,0 +1,163 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/mvader/pagecard/content"
+	"github.com/mvader/pagecard/opengraph"
+	"github.com/stretchr/testify/assert"
+)
+
+// valid are the metatags of a page without issues.
+var valid = []string{
+	"og:title", "Title",
+	"og:type", "article",
+	"og:url", "http://foo.bar/article",
+	"og:image", "http://foo.bar/image.png",
+	"og:image:width", "1200",
+	"og:image:height", "630",
+	"twitter:card", "summary_large_image",
+	"twitter:site", "@foo",
+}
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name   string
+		meta   []string
+		issues []*Issue
+	}{
+		{"valid", valid, nil},
+		{"missing everything", nil, []*Issue{
+			{RuleMissingRequired, Error, "og:title", "required property og:title is missing"},
+			{RuleMissingRequired, Error, "og:type", "required property og:type is missing"},
+			{RuleMissingRequired, Error, "og:image", "required property og:image is missing"},
+			{RuleMissingRequired, Error, "og:url", "required property og:url is missing"},
+			{RuleTwitterMissingCard, Warning, "twitter:card", "twitter:card is missing, so the page will be rendered as a summary card if it has a title"},
+			{RuleTwitterMissingRequired, Error, "twitter:title", "summary cards require twitter:title"},
+		}},
+		{"small image without size", append(valid[:6:6],
+			"og:type", "blog",
+			"og:image", "/relative.png",
+			"og:image:width", "100",
+			"og:image:height", "big",
+			"twitter:card", "summary",
+			"twitter:creator", "foo",
+		), []*Issue{
+			{RuleUnknownType, Warning, "og:type", `"blog" is not a known type`},
+			{RuleImageSizeMissing, Notice, "og:image", "declare og:image:width and og:image:height so the image can be rendered before it is downloaded"},
+			{RuleInvalidURL, Error, "og:image", `"/relative.png" is not an absolute http or https URL`},
+			{RuleInvalidNumber, Error, "og:image:height", `"big" is not a positive integer`},
+			{RuleConflictingDuplicate, Warning, "og:type", "og:type is declared 2 times with different values, only the last one is used"},
+			{RuleTwitterInvalidUsername, Warning, "twitter:creator", `"foo" is not a @username`},
+		}},
+		{"conflicts", append(valid,
+			"og:type", "video",
+			"og:title", "Title",
+			"og:title", "Other title",
+			"og:image:width", "100",
+		), []*Issue{
+			{RuleUnknownType, Warning, "og:type", `"video" is not a known type`},
+			{RuleImageTooSmall, Warning, "og:image", "image is 100x630, but it must be at least 200x200"},
+			{RuleConflictingDuplicate, Warning, "og:title", "og:title is declared 3 times with different values, only the last one is used"},
+			{RuleConflictingDuplicate, Warning, "og:type", "og:type is declared 2 times with different values, only the last one is used"},
+			{RuleTwitterImageTooSmall, Warning, "twitter:image", "image is 100x630, but summary_large_image cards require at least 300x157"},
+		}},
+		{"orphans", []string{
+			"og:image:width", "200",
+			"og:title", "Title",
+			"og:type", "music:custom",
+			"og:url", "http://foo.bar",
+			"og:image", "http://foo.bar/image.png",
+			"og:image:width", "150",
+			"og:image:height", "150",
+			"og:image", "http://foo.bar/image2.png",
+			"og:image:width", "1000",
+			"og:image:height", "1000",
+			"og:video:url", "http://foo.bar/video.mp4",
+			"twitter:card", "summary",
+		}, []*Issue{
+			{RuleOrphanProperty, Error, "og:image:width", "og:image:width must be declared after og:image"},
+			{RuleOrphanProperty, Error, "og:video:url", "og:video:url must be declared after og:video"},
+			{RuleImageTooSmall, Warning, "og:image", "image is 150x150, but it must be at least 200x200"},
+		}},
+		{"player", append(valid[:12:12],
+			"twitter:card", "player",
+			"twitter:player", "http://foo.bar/player",
+			"twitter:player:width", "0",
+		), []*Issue{
+			{RuleInvalidNumber, Error, "twitter:player:width", `"0" is not a positive integer`},
+			{RuleTwitterMissingRequired, Error, "twitter:site", "player cards require twitter:site"},
+			{RuleTwitterMissingRequired, Error, "twitter:player:height", "player cards require twitter:player:height"},
+			{RuleTwitterInsecurePlayer, Error, "twitter:player", "the player must be served over https"},
+		}},
+		{"app", append(valid[:12:12],
+			"twitter:card", "app",
+			"twitter:site", "@foo",
+		), []*Issue{
+			{RuleTwitterMissingRequired, Error, "twitter:app:id:iphone", "app cards require the ID of the app in at least one of iPhone, iPad or Google Play"},
+		}},
+		{"unknown type", append([]string{"og:type", "blog"}, valid[4:]...), []*Issue{
+			{RuleMissingRequired, Error, "og:title", "required property og:title is missing"},
+			{RuleUnknownType, Warning, "og:type", `"blog" is not a known type`},
+			{RuleTwitterMissingRequired, Error, "twitter:title", "summary_large_image cards require twitter:title"},
+		}},
+		{"invalid card", append(valid[:12:12], "twitter:card", "gallery"), []*Issue{
+			{RuleTwitterInvalidCard, Error, "twitter:card", `"gallery" is not a known card type`},
+		}},
+	}
+
+	assert := assert.New(t)
+	for _, c := range cases {
+		assert.Equal(Check(makeMeta(c.meta...)), c.issues, c.name)
+	}
+}
+
+func TestCheckUsesLastValue(t *testing.T) {
+	meta := makeMeta(append(valid,
+		"og:title", "",
+		"og:type", "blog",
+	)...)
+
+	assert := assert.New(t)
+	obj, err := opengraph.NewObject(meta)
+	assert.Nil(err)
+	assert.Equal(obj.Title, "")
+	assert.Equal(obj.Type, "blog")
+
+	assert.Equal(Check(meta), []*Issue{
+		{RuleMissingRequired, Error, "og:title", "required property og:title is missing"},
+		{RuleUnknownType, Warning, "og:type", `"blog" is not a known type`},
+		{RuleConflictingDuplicate, Warning, "og:title", "og:title is declared 2 times with different values, only the last one is used"},
+		{RuleConflictingDuplicate, Warning, "og:type", "og:type is declared 2 times with different values, only the last one is used"},
+		{RuleTwitterMissingRequired, Error, "twitter:title", "summary_large_image cards require twitter:title"},
+	})
+}
+
+func TestCheckIgnoresOtherAttributes(t *testing.T) {
+	meta := makeMeta(valid...)
+	meta = append(meta, &content.Meta{Name: "og:title", Value: "Other", Attr: content.AttrItemprop})
+	assert.Nil(t, Check(meta))
+}
+
+func TestSeverityString(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(Error.String(), "error")
+	assert.Equal(Warning.String(), "warning")
+	assert.Equal(Notice.String(), "notice")
+	assert.Equal(Severity(0).String(), "")
+
+	text, err := Warning.MarshalText()
+	assert.Nil(err)
+	assert.Equal(string(text), "warning")
+}
+
+func makeMeta(s ...string) []*content.Meta {
+	var meta []*content.Meta
+	for i := 0; i < len(s); i += 2 {
+		meta = append(meta, &content.Meta{
+			Name:  s[i],
+			Value: s[i+1],
+		})
+	}
+	return meta
+}